@@ -13,6 +13,8 @@ const (
 	FlagDateRange   string = "date-range"
 	FlagDepositInfo string = "info"
 	FlagNotes       string = "notes"
+	FlagTerms       string = "terms"
+	FlagLateFees    string = "late-fees"
 	FlagID          string = "id"
 	FlagIDs         string = "ids"
 
@@ -23,13 +25,34 @@ const (
 	FlagFrom        string = "from"
 	FlagDownloadExp string = "download-expense"
 	FlagInactive    string = "inactive"
+	FlagPeriod      string = "period"
+	FlagAddress     string = "address"
 
 	//Transaction
 	//Profile flags
 	FlagDueDurationDays string = "due-days"
+	FlagTaxJurisdiction string = "tax-jurisdiction"
+	FlagTaxID           string = "tax-id"
+	FlagTrusted         string = "trusted"
+	FlagSigners         string = "signers"
+	FlagThreshold       string = "threshold"
+	FlagLimit           string = "limit"
 
 	//Invoice flags
-	FlagDueDate string = "due-date"
+	FlagDueDate   string = "due-date"
+	FlagReason    string = "reason"
+	FlagItem      string = "item"
+	FlagItemsFile string = "items"
+	FlagComment   string = "comment"
+
+	//Dispute flags
+	FlagEvidence   string = "evidence"
+	FlagResolution string = "resolution"
+	FlagResponse   string = "response"
+
+	//Organisation flags
+	FlagRoles string = "roles"
+	FlagStep  string = "step"
 
 	//Expense flags
 	FlagReceipt   string = "receipt"
@@ -38,6 +61,19 @@ const (
 	//Payment flags
 	FlagTransactionID string = "tx-id"
 	FlagPaid          string = "paid"
+	FlagAllocation    string = "allocation"
+	FlagAmounts       string = "amounts"
+	FlagRefundID      string = "refund-id"
+	FlagOrg           string = "org"
+
+	//Schedule flags
+	FlagCadence     string = "cadence"
+	FlagStart       string = "start"
+	FlagEnd         string = "end"
+	FlagOccurrences string = "occurrences"
+
+	//Rate flags
+	FlagRateSource string = "source"
 
 	//Light-client flags
 	//The flags replace what are arguments in the full node
@@ -50,19 +86,44 @@ const (
 	TxNameProfileOpen       = "profile-open"
 	TxNameProfileEdit       = "profile-edit"
 	TxNameProfileDeactivate = "profile-deactivate"
+	TxNameProfileReactivate = "profile-reactivate"
+	TxNameProfileRotateKey  = "profile-rotate-key"
 	TxNameContractOpen      = "contract-open"
 	TxNameContractEdit      = "contract-edit"
 	TxNameExpenseOpen       = "expense-open"
 	TxNameExpenseEdit       = "expense-edit"
+	TxNameContractVoid      = "contract-void"
+	TxNameExpenseVoid       = "expense-void"
+	TxNameInvoiceApprove    = "invoice-approve"
+	TxNameInvoiceReject     = "invoice-reject"
+	TxNameInvoiceDispute    = "invoice-dispute"
+	TxNameInvoiceRespond    = "invoice-respond"
+	TxNameOrgMemberSet      = "org-member-set"
+	TxNameOrgMemberRemove   = "org-member-remove"
+	TxNameOrgChain          = "org-chain"
+	TxNameActionSign        = "action-sign"
 	TxNamePayment           = "payment"
+	TxNamePaymentReverse    = "payment-reverse"
+	TxNameRefund            = "refund"
+	TxNameCreditNote        = "credit-note"
+	TxNameRate              = "rate"
+	TxNameScheduleOpen      = "schedule-open"
+	TxNameScheduleCancel    = "schedule-cancel"
 
 	///////////////////////////////////
 	// light-client presenter apps
 	AppAdapterProfile             = "profile"
 	AppAdapterInvoice             = "invoice"
 	AppAdapterPayment             = "payment"
+	AppAdapterReversal            = "reversal"
+	AppAdapterRate                = "rate"
+	AppAdapterBalance             = "balance"
+	AppAdapterTaxSummary          = "tax-summary"
+	AppAdapterSchedule            = "schedule"
+	AppAdapterListSchedule        = "schedules"
 	AppAdapterListProfileActive   = "profiles"
 	AppAdapterListProfileInactive = "profiles-inactive"
 	AppAdapterListPayment         = "payments"
 	AppAdapterListInvoice         = "invoices"
+	AppAdapterListPendingAction   = "pending-actions"
 )
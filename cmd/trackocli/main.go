@@ -39,6 +39,13 @@ func main() {
 		trquery.QueryProfilesCmd,
 		trquery.QueryPaymentCmd,
 		trquery.QueryPaymentsCmd,
+		trquery.QueryReversalCmd,
+		trquery.QueryRateCmd,
+		trquery.QueryBalanceCmd,
+		trquery.QueryTaxSummaryCmd,
+		trquery.QueryScheduleCmd,
+		trquery.QuerySchedulesCmd,
+		trquery.QueryPendingActionsCmd,
 	)
 
 	//Initialize proofs and txs default basecoin behaviour
@@ -51,11 +58,29 @@ func main() {
 		trtx.ProfileOpenCmd,
 		trtx.ProfileEditCmd,
 		trtx.ProfileDeactivateCmd,
+		trtx.ProfileReactivateCmd,
+		trtx.ProfileRotateKeyCmd,
 		trtx.ContractOpenCmd,
 		trtx.ContractEditCmd,
 		trtx.ExpenseOpenCmd,
 		trtx.ExpenseEditCmd,
+		trtx.ContractVoidCmd,
+		trtx.ExpenseVoidCmd,
+		trtx.InvoiceApproveCmd,
+		trtx.InvoiceRejectCmd,
+		trtx.InvoiceDisputeCmd,
+		trtx.InvoiceRespondCmd,
+		trtx.OrgMemberSetCmd,
+		trtx.OrgMemberRemoveCmd,
+		trtx.OrgChainCmd,
+		trtx.ActionSignCmd,
 		trtx.PaymentCmd,
+		trtx.PaymentReverseCmd,
+		trtx.RefundCmd,
+		trtx.CreditNoteCmd,
+		trtx.RateCmd,
+		trtx.ScheduleOpenCmd,
+		trtx.ScheduleCancelCmd,
 	)
 
 	// set up the various commands to use
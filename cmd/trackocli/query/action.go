@@ -0,0 +1,55 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	wire "github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var QueryPendingActionsCmd = &cobra.Command{
+	Use:          "pending-actions",
+	Short:        "List the actions of multi-signature profiles awaiting signatures",
+	SilenceUsage: true,
+	RunE:         queryPendingActionsCmd,
+}
+
+func queryPendingActionsCmd(cmd *cobra.Command, args []string) error {
+
+	key := invoicer.ListPendingActionKey()
+	proof, err := getProof(key)
+	if err != nil {
+		return err
+	}
+	listActions, err := invoicer.GetListBytesFromWire(proof.Data())
+	if err != nil {
+		return err
+	}
+
+	var actions []types.PendingAction
+	for _, id := range listActions {
+		proof, err := getProof(invoicer.PendingActionKey(id))
+		if err != nil {
+			return err
+		}
+		action, err := invoicer.GetPendingActionFromWire(proof.Data())
+		if err != nil {
+			return err
+		}
+		actions = append(actions, action)
+	}
+
+	switch viper.GetString("output") {
+	case "text":
+		fmt.Println(string(wire.JSONBytes(actions))) //TODO Actually make text
+	case "json":
+		fmt.Println(string(wire.JSONBytes(actions)))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	wire "github.com/tendermint/go-wire"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+)
+
+//nolint
+var QueryBalanceCmd = &cobra.Command{
+	Use:          "balance [from] [to]",
+	Short:        "Query the credit held by a profile from overpayments by another",
+	SilenceUsage: true,
+	RunE:         queryBalanceCmd,
+}
+
+func queryBalanceCmd(cmd *cobra.Command, args []string) error {
+
+	if len(args) != 2 {
+		return trcmn.ErrCmdReqArg("from and to")
+	}
+	from, to := args[0], args[1]
+
+	key := invoicer.BalanceKey(from, to)
+	proof, err := getProof(key)
+	if err != nil {
+		return err
+	}
+	balance, err := invoicer.GetBalanceFromWire(proof.Data())
+	if err != nil {
+		return err
+	}
+
+	switch viper.GetString("output") {
+	case "text":
+		fmt.Println(string(wire.JSONBytes(balance))) //TODO Actually make text
+	case "json":
+		fmt.Println(string(wire.JSONBytes(balance)))
+	}
+	return nil
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -47,7 +50,7 @@ func init() {
 
 	FSQueryInvoices.Int(trcmn.FlagNum, 0, "Number of results to display, use 0 for no limit")
 	FSQueryInvoices.String(trcmn.FlagType, "",
-		"Limit the scope by using any of the following modifiers with commas: invoice,expense,open,closed")
+		"Limit the scope by using any of the following modifiers with commas: contract,expense,credit,open,closed,voided,pending,approved,rejected,disputed")
 	FSQueryInvoices.String(trcmn.FlagDateRange, "",
 		"Query within the date range start:end, where start/end are in the format YYYY-MM-DD, or empty. ex. --date 1991-10-21:")
 	FSQueryInvoices.String(trcmn.FlagFrom, "", "Only query for invoices from these addresses in the format <ADDR1>,<ADDR2>, etc.")
@@ -92,6 +95,18 @@ func queryInvoiceCmd(cmd *cobra.Command, args []string) error {
 	switch viper.GetString("output") {
 	case "text":
 		fmt.Println(string(jsonBytes)) //TODO Actually make text
+		err = printLineItems(invoice.GetCtx().LineItems)
+		if err != nil {
+			return err
+		}
+		err = printBalance(invoice.GetCtx())
+		if err != nil {
+			return err
+		}
+		err = printAllocations(invoice.GetCtx().Allocations)
+		if err != nil {
+			return err
+		}
 	case "json":
 		fmt.Println(string(jsonBytes)) //TODO Actually make text
 	}
@@ -129,33 +144,56 @@ func queryInvoicesCmd(cmd *cobra.Command, args []string) error {
 	froms, toes := processFlagFromTo()
 
 	ty := viper.GetString(trcmn.FlagType)
-	contractFilt, expenseFilt, openFilt, closedFilt := true, true, true, true
+	contractFilt, expenseFilt, creditFilt := true, true, true
+	openFilt, closedFilt, voidedFilt := true, true, true
+	pendingFilt, approvedFilt, rejectedFilt := true, true, true
+	disputedFilt := strings.Contains(ty, "disputed")
 
 	if viper.GetBool("debug") {
 		fmt.Printf("debug %v %v %v %v\n", len(ty), ty,
 			strings.Contains(ty, "open"), strings.Contains(ty, "closed"))
 	}
 	if len(ty) > 0 {
-		contractFilt, expenseFilt, openFilt, closedFilt = false, false, false, false
+		contractFilt, expenseFilt, creditFilt = false, false, false
+		openFilt, closedFilt, voidedFilt = false, false, false
+		pendingFilt, approvedFilt, rejectedFilt = false, false, false
 		if strings.Contains(ty, "contract") {
 			contractFilt = true
 		}
 		if strings.Contains(ty, "expense") {
 			expenseFilt = true
 		}
+		if strings.Contains(ty, "credit") {
+			creditFilt = true
+		}
 		if strings.Contains(ty, "open") {
 			openFilt = true
 		}
 		if strings.Contains(ty, "closed") {
 			closedFilt = true
 		}
+		if strings.Contains(ty, "voided") {
+			voidedFilt = true
+		}
+		if strings.Contains(ty, "pending") {
+			pendingFilt = true
+		}
+		if strings.Contains(ty, "approved") {
+			approvedFilt = true
+		}
+		if strings.Contains(ty, "rejected") {
+			rejectedFilt = true
+		}
 
 		//if a whole catagory is missing, turn it on
-		if !contractFilt && !expenseFilt {
-			contractFilt, expenseFilt = true, true
+		if !contractFilt && !expenseFilt && !creditFilt {
+			contractFilt, expenseFilt, creditFilt = true, true, true
 		}
-		if !openFilt && !closedFilt {
-			openFilt, closedFilt = true, true
+		if !openFilt && !closedFilt && !voidedFilt {
+			openFilt, closedFilt, voidedFilt = true, true, true
+		}
+		if !pendingFilt && !approvedFilt && !rejectedFilt {
+			pendingFilt, approvedFilt, rejectedFilt = true, true, true
 		}
 	}
 	if viper.GetBool("debug") {
@@ -214,18 +252,31 @@ func queryInvoicesCmd(cmd *cobra.Command, args []string) error {
 		//check the type filter flags
 		expense, isExpense := invoice.Unwrap().(*types.Expense)
 		_, isContract := invoice.Unwrap().(*types.Contract)
+		_, isCredit := invoice.Unwrap().(*types.CreditNote)
 
 		if viper.GetBool("debug") {
 			fmt.Printf("debug %v %v %v %v %v\n", isContract, isExpense, ctx.Open, openFilt, closedFilt)
 		}
 		switch {
-		case isContract && !contractFilt && expenseFilt:
+		case isContract && !contractFilt:
+			continue
+		case isExpense && !expenseFilt:
+			continue
+		case isCredit && !creditFilt:
+			continue
+		case ctx.Open && !openFilt:
+			continue
+		case ctx.Voided && !voidedFilt:
+			continue
+		case !ctx.Open && !ctx.Voided && !closedFilt:
+			continue
+		case ctx.Approval == types.ApprovalPending && !pendingFilt:
 			continue
-		case isExpense && contractFilt && !expenseFilt:
+		case ctx.Approved() && !approvedFilt:
 			continue
-		case ctx.Open && !openFilt && closedFilt:
+		case ctx.Approval == types.ApprovalRejected && !rejectedFilt:
 			continue
-		case !ctx.Open && openFilt && !closedFilt:
+		case disputedFilt && !ctx.Disputed():
 			continue
 		}
 
@@ -250,10 +301,28 @@ func queryInvoicesCmd(cmd *cobra.Command, args []string) error {
 	if viper.GetBool(trcmn.FlagSum) {
 		var sum *types.AmtCurTime
 		for _, invoice := range invoices {
+
+			//voided invoices are no longer owed, and invoices are only
+			//  owed once approved by their receiver and while undisputed
+			if invoice.GetCtx().Voided || !invoice.GetCtx().Approved() || invoice.GetCtx().Disputed() {
+				continue
+			}
 			unpaid, err := invoice.GetCtx().Unpaid()
 			if err != nil {
 				return err
 			}
+
+			//unapplied credit reduces the amount due
+			if _, isCredit := invoice.Unwrap().(*types.CreditNote); isCredit {
+				zero, err := types.NewAmtCurTime(unpaid.CurTime.Cur, unpaid.CurTime.Date, decimal.New(0, 0))
+				if err != nil {
+					return err
+				}
+				unpaid, err = zero.Minus(unpaid)
+				if err != nil {
+					return err
+				}
+			}
 			sum, err = sum.Add(unpaid)
 			if err != nil {
 				return err
@@ -285,6 +354,91 @@ func queryInvoicesCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printLineItems prints a table of an invoice's line items
+func printLineItems(items []*types.LineItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\nDESCRIPTION\tQUANTITY\tUNIT PRICE\tDISCOUNT\tTAX RATE\tTAX\tTOTAL")
+	for _, item := range items {
+		tax, err := item.Tax()
+		if err != nil {
+			return err
+		}
+		total, err := item.Total()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v%v\t%v\t%v\t%v%v\t%v%v\n",
+			item.Description,
+			item.Quantity,
+			item.UnitPrice.Amount, item.UnitPrice.CurTime.Cur,
+			item.Discount,
+			item.TaxRate,
+			tax.Amount, tax.CurTime.Cur,
+			total.Amount, total.CurTime.Cur)
+	}
+	return w.Flush()
+}
+
+// printAllocations prints the settlement history of an invoice
+func printAllocations(allocations []types.Allocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\nDATE\tTRANSACTION\tAMOUNT\tRATE\tFX GAIN/LOSS")
+	for _, allocation := range allocations {
+		var fx string
+		if allocation.FXGainLoss != nil {
+			fx = allocation.FXGainLoss.Amount + allocation.FXGainLoss.CurTime.Cur
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v%v\t%v\t%v\n",
+			allocation.Date.UTC().Format(common.TimeLayout),
+			allocation.TransactionID,
+			allocation.Amount.Amount, allocation.Amount.CurTime.Cur,
+			allocation.Rate, fx)
+	}
+	return w.Flush()
+}
+
+// printBalance prints the breakdown of the amount owed on an invoice
+func printBalance(ctx *types.Context) error {
+	unpaid, err := ctx.Unpaid()
+	if err != nil {
+		return err
+	}
+	paid, err := ctx.Paid()
+	if err != nil {
+		return err
+	}
+	fx, err := ctx.FXGainLoss()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "")
+	for _, row := range []struct {
+		name string
+		amt  *types.AmtCurTime
+	}{
+		{"Payable", ctx.Payable},
+		{"Early payment discount", ctx.Discount},
+		{"Late fee", ctx.AccruedFee},
+		{"Late interest", ctx.AccruedInterest},
+		{"Paid", paid},
+		{"Realized FX gain/loss", fx},
+		{"Credited", ctx.Credited},
+		{"Unpaid", unpaid},
+	} {
+		if row.amt != nil {
+			fmt.Fprintf(w, "%v\t%v%v\n", row.name, row.amt.Amount, row.amt.CurTime.Cur)
+		}
+	}
+	return w.Flush()
+}
+
 func processFlagFromTo() (froms, toes []string) {
 	from := viper.GetString(trcmn.FlagFrom)
 	to := viper.GetString(trcmn.FlagTo)
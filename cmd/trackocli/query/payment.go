@@ -30,6 +30,13 @@ var (
 		SilenceUsage: true,
 		RunE:         queryPaymentsCmd,
 	}
+
+	QueryReversalCmd = &cobra.Command{
+		Use:          "reversal [id]",
+		Short:        "Query the reversal or refund of a payment by its transaction ID",
+		SilenceUsage: true,
+		RunE:         queryReversalCmd,
+	}
 )
 
 func init() {
@@ -81,6 +88,31 @@ func queryPaymentCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func queryReversalCmd(cmd *cobra.Command, args []string) error {
+
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("transactionID")
+	}
+
+	key := invoicer.ReversalKey(args[0])
+	proof, err := getProof(key)
+	if err != nil {
+		return err
+	}
+	reversal, err := invoicer.GetReversalFromWire(proof.Data())
+	if err != nil {
+		return err
+	}
+
+	switch viper.GetString("output") {
+	case "text":
+		fmt.Println(string(wire.JSONBytes(reversal))) //TODO Actually make text
+	case "json":
+		fmt.Println(string(wire.JSONBytes(reversal)))
+	}
+	return nil
+}
+
 // DoQueryPaymentsCmd is the workhorse of the heavy and light cli query profiles commands
 func queryPaymentsCmd(cmd *cobra.Command, args []string) error {
 
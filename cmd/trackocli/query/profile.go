@@ -1,13 +1,16 @@
 package query
 
 import (
+	"encoding/hex"
 	"fmt"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	wire "github.com/tendermint/go-wire"
+	cmn "github.com/tendermint/tmlibs/common"
 
 	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
 	"github.com/tendermint/trackomatron/plugins/invoicer"
@@ -34,15 +37,34 @@ func init() {
 	FSQueryProfiles := flag.NewFlagSet("", flag.ContinueOnError)
 	FSQueryProfiles.Bool(trcmn.FlagInactive, false, "List inactive profiles")
 	QueryProfilesCmd.Flags().AddFlagSet(FSQueryProfiles)
+
+	QueryProfileCmd.Flags().String(trcmn.FlagAddress, "",
+		"Query the profile controlled by the address (hex) instead of by name")
 }
 
 // DoQueryProfileCmd is the workhorse of the heavy and light cli query profile commands
 func queryProfileCmd(cmd *cobra.Command, args []string) error {
-	if len(args) != 1 {
-		return trcmn.ErrCmdReqArg("name")
+	var name string
+	if addressHex := viper.GetString(trcmn.FlagAddress); len(addressHex) > 0 {
+		address, err := hex.DecodeString(cmn.StripHex(addressHex))
+		if err != nil {
+			return errors.Wrapf(err, "Address %v must be hex", addressHex)
+		}
+		proof, err := getProof(invoicer.AddressKey(address))
+		if err != nil {
+			return err
+		}
+		name, err = invoicer.GetNameFromWire(proof.Data())
+		if err != nil {
+			return err
+		}
+	} else {
+		if len(args) != 1 {
+			return trcmn.ErrCmdReqArg("name")
+		}
+		name = args[0]
 	}
 
-	name := args[0]
 	if len(name) == 0 {
 		return trcmn.ErrBadQuery("name")
 	}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	wire "github.com/tendermint/go-wire"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+)
+
+//nolint
+var QueryRateCmd = &cobra.Command{
+	Use:          "rate [from] [to]",
+	Short:        "Query a posted exchange rate",
+	SilenceUsage: true,
+	RunE:         queryRateCmd,
+}
+
+func init() {
+	FSQueryRate := flag.NewFlagSet("", flag.ContinueOnError)
+	FSQueryRate.String(trcmn.FlagDate, "", "Date of the rate in the format YYYY-MM-DD eg. 2016-12-31 (default: today)")
+	QueryRateCmd.Flags().AddFlagSet(FSQueryRate)
+}
+
+// queryRateCmd is the workhorse of the heavy and light cli query rate commands
+func queryRateCmd(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return trcmn.ErrCmdReqArg("from, to")
+	}
+
+	date := time.Now()
+	if flagDate := viper.GetString(trcmn.FlagDate); len(flagDate) > 0 {
+		var err error
+		date, err = time.Parse(common.TimeLayout, flagDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	key := invoicer.RateKey(args[0], args[1], date)
+	proof, err := getProof(key)
+	if err != nil {
+		return err
+	}
+	rate, err := invoicer.GetRateFromWire(proof.Data())
+	if err != nil {
+		return err
+	}
+
+	switch viper.GetString("output") {
+	case "text":
+		fmt.Println(string(wire.JSONBytes(rate))) //TODO Actually make text
+	case "json":
+		fmt.Println(string(wire.JSONBytes(rate)))
+	}
+	return nil
+}
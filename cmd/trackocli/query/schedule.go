@@ -0,0 +1,99 @@
+package query
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	wire "github.com/tendermint/go-wire"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var (
+	QueryScheduleCmd = &cobra.Command{
+		Use:          "schedule [id]",
+		Short:        "Query a recurring invoice schedule by ID",
+		SilenceUsage: true,
+		RunE:         queryScheduleCmd,
+	}
+
+	QuerySchedulesCmd = &cobra.Command{
+		Use:          "schedules",
+		Short:        "List the active recurring invoice schedules",
+		SilenceUsage: true,
+		RunE:         querySchedulesCmd,
+	}
+)
+
+func queryScheduleCmd(cmd *cobra.Command, args []string) error {
+
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("id")
+	}
+	if !cmn.IsHex(args[0]) {
+		return trcmn.ErrBadHexID
+	}
+	id, err := hex.DecodeString(cmn.StripHex(args[0]))
+	if err != nil {
+		return err
+	}
+
+	key := invoicer.ScheduleKey(id)
+	proof, err := getProof(key)
+	if err != nil {
+		return err
+	}
+	schedule, err := invoicer.GetScheduleFromWire(proof.Data())
+	if err != nil {
+		return err
+	}
+
+	switch viper.GetString("output") {
+	case "text":
+		fmt.Println(string(wire.JSONBytes(schedule))) //TODO Actually make text
+	case "json":
+		fmt.Println(string(wire.JSONBytes(schedule)))
+	}
+	return nil
+}
+
+func querySchedulesCmd(cmd *cobra.Command, args []string) error {
+
+	key := invoicer.ListScheduleKey()
+	proof, err := getProof(key)
+	if err != nil {
+		return err
+	}
+	listSchedules, err := invoicer.GetListBytesFromWire(proof.Data())
+	if err != nil {
+		return err
+	}
+
+	var schedules []types.Schedule
+	for _, id := range listSchedules {
+		proof, err := getProof(invoicer.ScheduleKey(id))
+		if err != nil {
+			return err
+		}
+		schedule, err := invoicer.GetScheduleFromWire(proof.Data())
+		if err != nil {
+			return err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	switch viper.GetString("output") {
+	case "text":
+		fmt.Println(string(wire.JSONBytes(schedules))) //TODO Actually make text
+	case "json":
+		fmt.Println(string(wire.JSONBytes(schedules)))
+	}
+	return nil
+}
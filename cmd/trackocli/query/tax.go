@@ -0,0 +1,91 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	wire "github.com/tendermint/go-wire"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/tax"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var QueryTaxSummaryCmd = &cobra.Command{
+	Use:          "tax-summary [name]",
+	Short:        "Total the tax collected and paid by a profile per period",
+	SilenceUsage: true,
+	RunE:         queryTaxSummaryCmd,
+}
+
+func init() {
+	FSQueryTaxSummary := flag.NewFlagSet("", flag.ContinueOnError)
+	FSQueryTaxSummary.String(trcmn.FlagPeriod, tax.PeriodQuarter, "Period to total the tax over: month, quarter or year")
+	FSQueryTaxSummary.String(trcmn.FlagDateRange, "",
+		"Query within the date range start:end, where start/end are in the format YYYY-MM-DD, or empty. ex. --date 1991-10-21:")
+	QueryTaxSummaryCmd.Flags().AddFlagSet(FSQueryTaxSummary)
+}
+
+// queryTaxSummaryCmd is the workhorse of the heavy and light cli query tax-summary commands
+func queryTaxSummaryCmd(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("name")
+	}
+	name := args[0]
+
+	startDate, endDate, err := processFlagDateRange()
+	if err != nil {
+		return err
+	}
+
+	proof, err := getProof(invoicer.ListInvoiceKey())
+	if err != nil {
+		return err
+	}
+	listInvoices, err := invoicer.GetListBytesFromWire(proof.Data())
+	if err != nil {
+		return err
+	}
+
+	//retrieve all the invoices sent or received by the profile within the date range
+	var invoices []types.Invoice
+	for _, id := range listInvoices {
+		proof, err := getProof(invoicer.InvoiceKey(id))
+		if err != nil {
+			return err
+		}
+		invoice, err := invoicer.GetInvoiceFromWire(proof.Data())
+		if err != nil {
+			return errors.Errorf("Bad invoice in active invoice list %x \n%v", id, err)
+		}
+		ctx := invoice.GetCtx()
+		if ctx.Sender != name && ctx.Receiver != name {
+			continue
+		}
+		d := ctx.Invoiced.CurTime.Date
+		if (!startDate.IsZero() && d.Before(startDate)) ||
+			(!endDate.IsZero() && d.After(endDate)) {
+			continue
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	summaries, err := tax.Summarize(name, invoices, viper.GetString(trcmn.FlagPeriod))
+	if err != nil {
+		return err
+	}
+
+	switch viper.GetString("output") {
+	case "text":
+		fmt.Println(string(wire.JSONBytes(summaries))) //TODO Actually make text
+	case "json":
+		fmt.Println(string(wire.JSONBytes(summaries)))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package tx
+
+import (
+	"encoding/hex"
+
+	"github.com/spf13/cobra"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var ActionSignCmd = &cobra.Command{
+	Use:   "action-sign [id]",
+	Short: "Approve an action of a multi-signature profile awaiting signatures",
+	RunE:  actionSignCmd,
+}
+
+func init() {
+	//add the default flags
+	bcmd.AddAppTxFlags(ActionSignCmd.Flags())
+}
+
+func actionSignCmd(cmd *cobra.Command, args []string) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("id")
+	}
+	if !cmn.IsHex(args[0]) {
+		return trcmn.ErrBadHexID
+	}
+	id, err := hex.DecodeString(cmn.StripHex(args[0]))
+	if err != nil {
+		return err
+	}
+
+	txSign := types.TxActionSign{
+		ID:         id,
+		SenderAddr: txInput.Address,
+	}
+	data := invoicer.MarshalWithTB(txSign, invoicer.TBTxActionSign)
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
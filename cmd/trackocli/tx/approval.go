@@ -0,0 +1,95 @@
+package tx
+
+import (
+	"encoding/hex"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var (
+	InvoiceApproveCmd = &cobra.Command{
+		Use:   "invoice-approve [id]",
+		Short: "Approve an invoice sent to you so that it may be paid",
+		RunE:  invoiceApproveCmd,
+	}
+
+	InvoiceRejectCmd = &cobra.Command{
+		Use:   "invoice-reject [id]",
+		Short: "Reject a pending invoice sent to you",
+		RunE:  invoiceRejectCmd,
+	}
+)
+
+func init() {
+	fsTxApproval := flag.NewFlagSet("", flag.ContinueOnError)
+
+	//add the default flags
+	bcmd.AddAppTxFlags(fsTxApproval)
+
+	fsTxApproval.String(trcmn.FlagComment, "", "Comment recorded with the decision")
+
+	InvoiceApproveCmd.Flags().AddFlagSet(fsTxApproval)
+	InvoiceRejectCmd.Flags().AddFlagSet(fsTxApproval)
+}
+
+func invoiceApproveCmd(cmd *cobra.Command, args []string) error {
+	return approvalCmd(cmd, args, invoicer.TBTxInvoiceApprove)
+}
+func invoiceRejectCmd(cmd *cobra.Command, args []string) error {
+	return approvalCmd(cmd, args, invoicer.TBTxInvoiceReject)
+}
+
+func approvalCmd(cmd *cobra.Command, args []string, TBTx byte) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("id")
+	}
+	if !cmn.IsHex(args[0]) {
+		return trcmn.ErrBadHexID
+	}
+	id, err := hex.DecodeString(cmn.StripHex(args[0]))
+	if err != nil {
+		return err
+	}
+
+	txApproval := types.TxInvoiceApproval{
+		ID:         id,
+		SenderAddr: txInput.Address,
+		Comment:    viper.GetString(trcmn.FlagComment),
+	}
+	data := invoicer.MarshalWithTB(txApproval, TBTx)
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
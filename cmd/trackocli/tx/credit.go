@@ -0,0 +1,116 @@
+package tx
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var CreditNoteCmd = &cobra.Command{
+	Use:   "credit-note [amount]",
+	Short: "Issue a credit note of amount <value><currency> against invoices you sent",
+	RunE:  creditNoteCmd,
+}
+
+func init() {
+	fsTxCreditNote := flag.NewFlagSet("", flag.ContinueOnError)
+
+	//add the default flags
+	bcmd.AddAppTxFlags(fsTxCreditNote)
+
+	fsTxCreditNote.String(trcmn.FlagIDs, "", "IDs of the invoices to credit <id1>,<id2>,<id3>... ")
+	fsTxCreditNote.String(trcmn.FlagNotes, "", "Notes regarding the credit")
+	fsTxCreditNote.String(trcmn.FlagDate, "", "Credit note date in the format YYYY-MM-DD eg. 2016-12-31 (default: today)")
+
+	CreditNoteCmd.Flags().AddFlagSet(fsTxCreditNote)
+}
+
+func creditNoteCmd(cmd *cobra.Command, args []string) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("amount<amt><cur>")
+	}
+
+	data, err := creditNoteTx(txInput.Address, args[0])
+	if err != nil {
+		return err
+	}
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
+
+// creditNoteTx Generates the tendermint TX used by the light and heavy client
+func creditNoteTx(senderAddr []byte, amountStr string) ([]byte, error) {
+
+	flagIDs := viper.GetString(trcmn.FlagIDs)
+	if len(flagIDs) == 0 {
+		return nil, errors.New("Need the IDs of the invoices to credit, please specify through the flag --ids")
+	}
+	var ids [][]byte
+	for _, idHex := range strings.Split(flagIDs, ",") {
+		if !cmn.IsHex(idHex) {
+			return nil, trcmn.ErrBadHexID
+		}
+		id, err := hex.DecodeString(cmn.StripHex(idHex))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	date := viper.GetString(trcmn.FlagDate)
+	if len(date) > 0 {
+		if _, err := time.Parse(common.TimeLayout, date); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := types.ParseAmtCurTime(amountStr, time.Now()); err != nil {
+		return nil, err
+	}
+
+	tx := types.TxCreditNote{
+		SenderAddr: senderAddr,
+		InvoiceIDs: ids,
+		Amount:     amountStr,
+		Notes:      viper.GetString(trcmn.FlagNotes),
+		Date:       date,
+	}
+
+	return invoicer.MarshalWithTB(tx, invoicer.TBTxCreditNote), nil
+}
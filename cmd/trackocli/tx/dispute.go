@@ -0,0 +1,146 @@
+package tx
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var (
+	InvoiceDisputeCmd = &cobra.Command{
+		Use:   "invoice-dispute [id]",
+		Short: "Dispute an invoice sent to you, withholding it from automatic payment",
+		RunE:  invoiceDisputeCmd,
+	}
+
+	InvoiceRespondCmd = &cobra.Command{
+		Use:   "invoice-respond [id]",
+		Short: "Resolve the dispute of an invoice you sent by withdrawing or upholding it",
+		RunE:  invoiceRespondCmd,
+	}
+)
+
+func init() {
+	fsTxDispute := flag.NewFlagSet("", flag.ContinueOnError)
+	fsTxRespond := flag.NewFlagSet("", flag.ContinueOnError)
+
+	//add the default flags
+	bcmd.AddAppTxFlags(fsTxDispute)
+	bcmd.AddAppTxFlags(fsTxRespond)
+
+	fsTxDispute.String(trcmn.FlagReason, "", "Reason the invoice is disputed")
+	fsTxDispute.String(trcmn.FlagEvidence, "", "Hash (hex) of a document evidencing the dispute")
+	fsTxRespond.String(trcmn.FlagResolution, "",
+		"Resolution of the dispute, withdrawn to void the invoice or upheld to keep it")
+	fsTxRespond.String(trcmn.FlagResponse, "", "Response to the dispute")
+
+	InvoiceDisputeCmd.Flags().AddFlagSet(fsTxDispute)
+	InvoiceRespondCmd.Flags().AddFlagSet(fsTxRespond)
+}
+
+func invoiceDisputeCmd(cmd *cobra.Command, args []string) error {
+	return disputeCmd(args, invoicer.TBTxInvoiceDispute)
+}
+func invoiceRespondCmd(cmd *cobra.Command, args []string) error {
+	return disputeCmd(args, invoicer.TBTxInvoiceRespond)
+}
+
+func disputeCmd(args []string, TBTx byte) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("id")
+	}
+	if !cmn.IsHex(args[0]) {
+		return trcmn.ErrBadHexID
+	}
+	id, err := hex.DecodeString(cmn.StripHex(args[0]))
+	if err != nil {
+		return err
+	}
+
+	data, err := disputeTx(txInput.Address, id, TBTx)
+	if err != nil {
+		return err
+	}
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
+
+// disputeTx Generates the tendermint TX used by the light and heavy client
+func disputeTx(senderAddr, id []byte, TBTx byte) ([]byte, error) {
+
+	if TBTx == invoicer.TBTxInvoiceRespond {
+		resolution := viper.GetString(trcmn.FlagResolution)
+		switch resolution {
+		case types.DisputeWithdrawn, types.DisputeUpheld:
+		case types.DisputeAdjusted:
+			return nil, errors.New("A disputed invoice is adjusted by editing it with contract-edit or expense-edit")
+		default:
+			return nil, errors.New("Need a resolution of withdrawn or upheld, please specify through the flag --resolution")
+		}
+		response := viper.GetString(trcmn.FlagResponse)
+		if len(response) == 0 {
+			return nil, errors.New("Need a response, please specify through the flag --response")
+		}
+		tx := types.TxInvoiceRespond{
+			ID:         id,
+			SenderAddr: senderAddr,
+			Resolution: resolution,
+			Response:   response,
+		}
+		return invoicer.MarshalWithTB(tx, TBTx), nil
+	}
+
+	reason := viper.GetString(trcmn.FlagReason)
+	if len(reason) == 0 {
+		return nil, errors.New("Need a reason to dispute, please specify through the flag --reason")
+	}
+	var evidence []byte
+	if evidenceHex := viper.GetString(trcmn.FlagEvidence); len(evidenceHex) > 0 {
+		var err error
+		evidence, err = hex.DecodeString(cmn.StripHex(evidenceHex))
+		if err != nil {
+			return nil, errors.New("Evidence must be a hex hash")
+		}
+	}
+	tx := types.TxInvoiceDispute{
+		ID:         id,
+		SenderAddr: senderAddr,
+		Reason:     reason,
+		Evidence:   evidence,
+	}
+	return invoicer.MarshalWithTB(tx, TBTx), nil
+}
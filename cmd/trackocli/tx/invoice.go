@@ -2,11 +2,17 @@ package tx
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 
 	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
 	btypes "github.com/tendermint/basecoin/types"
@@ -43,13 +49,27 @@ var (
 		Short: "Edit an open expense invoice to amount <value><currency>",
 		RunE:  expenseEditCmd,
 	}
+
+	ContractVoidCmd = &cobra.Command{
+		Use:   "contract-void [id]",
+		Short: "Void an open contract invoice which has received no payments",
+		RunE:  contractVoidCmd,
+	}
+
+	ExpenseVoidCmd = &cobra.Command{
+		Use:   "expense-void [id]",
+		Short: "Void an open expense invoice which has received no payments",
+		RunE:  expenseVoidCmd,
+	}
 )
 
 func init() {
 
 	fsTxInvoice := flag.NewFlagSet("", flag.ContinueOnError)
+	fsTxInvoiceDates := flag.NewFlagSet("", flag.ContinueOnError)
 	fsTxExpense := flag.NewFlagSet("", flag.ContinueOnError)
 	fsTxInvoiceEdit := flag.NewFlagSet("", flag.ContinueOnError)
+	fsTxInvoiceVoid := flag.NewFlagSet("", flag.ContinueOnError)
 
 	//only need to add apptx flags to this flagset as it's included in all invoice commands
 	bcmd.AddAppTxFlags(fsTxInvoice)
@@ -58,20 +78,35 @@ func init() {
 	fsTxInvoice.String(trcmn.FlagDepositInfo, "", "Deposit information for invoice payment (default: profile)")
 	fsTxInvoice.String(trcmn.FlagNotes, "", "Notes regarding the expense")
 	fsTxInvoice.String(trcmn.FlagCur, "", "Currency which invoice should be paid in")
-	fsTxInvoice.String(trcmn.FlagDate, "", "Invoice demon date in the format YYYY-MM-DD eg. 2016-12-31 (default: today)")
-	fsTxInvoice.String(trcmn.FlagDueDate, "", "Invoice due date in the format YYYY-MM-DD eg. 2016-12-31 (default: profile)")
+	fsTxInvoiceDates.String(trcmn.FlagDate, "", "Invoice demon date in the format YYYY-MM-DD eg. 2016-12-31 (default: today)")
+	fsTxInvoiceDates.String(trcmn.FlagDueDate, "", "Invoice due date in the format YYYY-MM-DD eg. 2016-12-31 (default: profile)")
+	fsTxInvoice.StringArray(trcmn.FlagItem, nil,
+		"Line item in the format <description>|<quantity>|<unit price>[|<tax rate>[|<discount>]], may be repeated")
+	fsTxInvoice.String(trcmn.FlagTerms, "", "Payment terms eg. \"2/10 net 30\" (default: profile)")
+	fsTxInvoice.String(trcmn.FlagLateFees, "", "Late fee policy eg. \"fee 25USD, interest 1.5%/month, cap 10%\" (default: profile)")
+	fsTxInvoice.String(trcmn.FlagItemsFile, "", "JSON or YAML file containing a list of line items")
 	fsTxExpense.String(trcmn.FlagReceipt, "", "Directory to receipt document file")
-	fsTxExpense.String(trcmn.FlagTaxesPaid, "", "Taxes amount in the format <decimal><currency> eg. 10.23usd")
+	fsTxExpense.String(trcmn.FlagTaxesPaid, "",
+		"Taxes amount in the format <decimal><currency> eg. 10.23USD (default: included tax of the profile jurisdiction)")
 	fsTxInvoiceEdit.String(trcmn.FlagID, "", "ID (hex) of the invoice to modify")
 
-	ContractOpenCmd.Flags().AddFlagSet(fsTxInvoice)
-	ContractEditCmd.Flags().AddFlagSet(fsTxInvoice)
+	bcmd.AddAppTxFlags(fsTxInvoiceVoid)
+	fsTxInvoiceVoid.String(trcmn.FlagReason, "", "Reason the invoice is voided")
+
+	for _, cmd := range []*cobra.Command{ContractOpenCmd, ContractEditCmd, ExpenseOpenCmd, ExpenseEditCmd} {
+		cmd.Flags().AddFlagSet(fsTxInvoice)
+		cmd.Flags().AddFlagSet(fsTxInvoiceDates)
+	}
 	ContractEditCmd.Flags().AddFlagSet(fsTxInvoiceEdit)
-	ExpenseOpenCmd.Flags().AddFlagSet(fsTxInvoice)
 	ExpenseOpenCmd.Flags().AddFlagSet(fsTxExpense)
-	ExpenseEditCmd.Flags().AddFlagSet(fsTxInvoice)
 	ExpenseEditCmd.Flags().AddFlagSet(fsTxExpense)
 	ExpenseEditCmd.Flags().AddFlagSet(fsTxInvoiceEdit)
+
+	ContractVoidCmd.Flags().AddFlagSet(fsTxInvoiceVoid)
+	ExpenseVoidCmd.Flags().AddFlagSet(fsTxInvoiceVoid)
+
+	//schedules issue contract invoices on dates determined by their cadence
+	ScheduleOpenCmd.Flags().AddFlagSet(fsTxInvoice)
 }
 
 func contractOpenCmd(cmd *cobra.Command, args []string) error {
@@ -87,6 +122,13 @@ func expenseEditCmd(cmd *cobra.Command, args []string) error {
 	return invoiceCmd(cmd, args, invoicer.TBTxExpenseEdit)
 }
 
+func contractVoidCmd(cmd *cobra.Command, args []string) error {
+	return voidCmd(cmd, args, invoicer.TBTxContractVoid)
+}
+func expenseVoidCmd(cmd *cobra.Command, args []string) error {
+	return voidCmd(cmd, args, invoicer.TBTxExpenseVoid)
+}
+
 func invoiceCmd(cmd *cobra.Command, args []string, TBTx byte) error {
 	// Note: we don't support loading apptx from json currently, so skip that
 
@@ -97,15 +139,16 @@ func invoiceCmd(cmd *cobra.Command, args []string, TBTx byte) error {
 	}
 
 	// Retrieve the app-specific flags/args
-	if len(args) != 1 {
-		return trcmn.ErrCmdReqArg("amount<amt><cur>")
+	amountStr, lineItems, err := readInvoiceArgs(cmd, args)
+	if err != nil {
+		return err
 	}
-	amountStr := args[0]
 
-	data, err := invoiceTx(TBTx, txInput.Address, amountStr)
+	txInvoice, err := invoiceTx(TBTx, txInput.Address, amountStr, lineItems)
 	if err != nil {
 		return err
 	}
+	data := invoicer.MarshalWithTB(txInvoice, TBTx)
 
 	// Create AppTx and broadcast
 	tx := &btypes.AppTx{
@@ -124,40 +167,141 @@ func invoiceCmd(cmd *cobra.Command, args []string, TBTx byte) error {
 	return txcmd.OutputTx(res)
 }
 
+func voidCmd(cmd *cobra.Command, args []string, TBTx byte) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("id")
+	}
+	if !cmn.IsHex(args[0]) {
+		return trcmn.ErrBadHexID
+	}
+	id, err := hex.DecodeString(cmn.StripHex(args[0]))
+	if err != nil {
+		return err
+	}
+	reason := viper.GetString(trcmn.FlagReason)
+	if len(reason) == 0 {
+		return errors.New("Need a reason to void, please specify through the flag --reason")
+	}
+
+	txVoid := types.TxInvoiceVoid{
+		ID:         id,
+		SenderAddr: txInput.Address,
+		Reason:     reason,
+	}
+	data := invoicer.MarshalWithTB(txVoid, TBTx)
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
+
+// readInvoiceArgs reads the invoice amount argument and line item flags,
+//   the amount may be omitted if it is derived from line items
+func readInvoiceArgs(cmd *cobra.Command, args []string) (amountStr string,
+	lineItems []types.TxLineItem, err error) {
+
+	itemFlags, err := cmd.Flags().GetStringArray(trcmn.FlagItem)
+	if err != nil {
+		return
+	}
+	lineItems, err = readLineItems(itemFlags, viper.GetString(trcmn.FlagItemsFile))
+	if err != nil {
+		return
+	}
+
+	switch {
+	case len(args) == 1:
+		amountStr = args[0]
+	case len(args) > 1 || len(lineItems) == 0:
+		err = trcmn.ErrCmdReqArg("amount<amt><cur>")
+	}
+	return
+}
+
 // invoiceTx Generates the Tendermint tx
-func invoiceTx(TBTx byte, senderAddr []byte, amountStr string) ([]byte, error) {
+func invoiceTx(TBTx byte, senderAddr []byte, amountStr string,
+	lineItems []types.TxLineItem) (tx types.TxInvoice, err error) {
 
 	var id []byte
 
 	//if editing
-	var err error
 	if TBTx == invoicer.TBTxContractEdit || //require this flag if
 		TBTx == invoicer.TBTxExpenseEdit { //require this flag if
 
 		//get the old id to remove if editing
 		idRaw := viper.GetString(trcmn.FlagID)
 		if len(idRaw) == 0 {
-			return nil, errors.New("Need the id to edit, please specify through the flag --id")
+			return tx, errors.New("Need the id to edit, please specify through the flag --id")
 		}
 		if !cmn.IsHex(idRaw) {
-			return nil, trcmn.ErrBadHexID
+			return tx, trcmn.ErrBadHexID
 		}
 		id, err = hex.DecodeString(cmn.StripHex(idRaw))
 		if err != nil {
-			return nil, err
+			return tx, err
 		}
 	}
 
-	//check for expenses flags required
+	//reject malformed or ambiguous amounts before broadcasting
+	if len(amountStr) > 0 {
+		if _, err := types.ParseAmtCurTime(amountStr, time.Now()); err != nil {
+			return tx, err
+		}
+	}
+	if len(lineItems) > 0 {
+		items, err := types.ParseLineItems(lineItems, time.Now())
+		if err != nil {
+			return tx, err
+		}
+		if _, err = types.SumLineItems(items); err != nil {
+			return tx, err
+		}
+	}
+
+	if terms := viper.GetString(trcmn.FlagTerms); len(terms) > 0 {
+		if _, err := types.ParsePaymentTerms(terms); err != nil {
+			return tx, err
+		}
+	}
+
+	if lateFees := viper.GetString(trcmn.FlagLateFees); len(lateFees) > 0 {
+		if _, err := types.ParseLateFeePolicy(lateFees); err != nil {
+			return tx, err
+		}
+	}
+
+	//check the expense flags
 	if TBTx == invoicer.TBTxExpenseOpen ||
 		TBTx == invoicer.TBTxExpenseEdit {
 
-		if len(viper.GetString(trcmn.FlagTaxesPaid)) == 0 {
-			return nil, errors.New("Need --taxes flag")
+		taxes := viper.GetString(trcmn.FlagTaxesPaid)
+		if len(taxes) > 0 {
+			if _, err := types.ParseAmtCurTime(taxes, time.Now()); err != nil {
+				return tx, err
+			}
 		}
 	}
 
-	tx := types.TxInvoice{
+	tx = types.TxInvoice{
 		EditID:      id,
 		Amount:      amountStr,
 		SenderAddr:  senderAddr,
@@ -169,7 +313,53 @@ func invoiceTx(TBTx byte, senderAddr []byte, amountStr string) ([]byte, error) {
 		DueDate:     viper.GetString(trcmn.FlagDueDate),
 		Receipt:     viper.GetString(trcmn.FlagReceipt),
 		TaxesPaid:   viper.GetString(trcmn.FlagTaxesPaid),
+		LineItems:   lineItems,
+		Terms:       viper.GetString(trcmn.FlagTerms),
+		LateFees:    viper.GetString(trcmn.FlagLateFees),
+	}
+
+	return tx, nil
+}
+
+// readLineItems reads line items from the item flags followed by the items file
+func readLineItems(itemFlags []string, itemsFile string) (items []types.TxLineItem, err error) {
+
+	for _, itemFlag := range itemFlags {
+		fields := strings.Split(itemFlag, "|")
+		if len(fields) < 3 || len(fields) > 5 {
+			return nil, errors.New("Line items must be in the format " +
+				"<description>|<quantity>|<unit price>[|<tax rate>[|<discount>]]")
+		}
+		for len(fields) < 5 {
+			fields = append(fields, "")
+		}
+		items = append(items, types.TxLineItem{
+			Description: strings.TrimSpace(fields[0]),
+			Quantity:    strings.TrimSpace(fields[1]),
+			UnitPrice:   strings.TrimSpace(fields[2]),
+			TaxRate:     strings.TrimSpace(fields[3]),
+			Discount:    strings.TrimSpace(fields[4]),
+		})
 	}
 
-	return invoicer.MarshalWithTB(tx, TBTx), nil
+	if len(itemsFile) == 0 {
+		return items, nil
+	}
+	bytes, err := ioutil.ReadFile(itemsFile)
+	if err != nil {
+		return nil, errors.New("Problem reading line items file: " + err.Error())
+	}
+	var fileItems []types.TxLineItem
+	switch strings.ToLower(path.Ext(itemsFile)) {
+	case ".json":
+		err = json.Unmarshal(bytes, &fileItems)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bytes, &fileItems)
+	default:
+		return nil, errors.New("Line items file must be .json, .yaml or .yml")
+	}
+	if err != nil {
+		return nil, errors.New("Problem decoding line items file: " + err.Error())
+	}
+	return append(items, fileItems...), nil
 }
@@ -0,0 +1,164 @@
+package tx
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var (
+	OrgMemberSetCmd = &cobra.Command{
+		Use:   "org-member-set [org] [address]",
+		Short: "Add a member to an organisation profile or change its roles",
+		RunE:  orgMemberSetCmd,
+	}
+
+	OrgMemberRemoveCmd = &cobra.Command{
+		Use:   "org-member-remove [org] [address]",
+		Short: "Remove a member from an organisation profile",
+		RunE:  orgMemberRemoveCmd,
+	}
+
+	OrgChainCmd = &cobra.Command{
+		Use:   "org-chain [org]",
+		Short: "Set the approval chain of expenses sent to an organisation profile",
+		RunE:  orgChainCmd,
+	}
+)
+
+func init() {
+	fsTxOrgMember := flag.NewFlagSet("", flag.ContinueOnError)
+	fsTxOrgChain := flag.NewFlagSet("", flag.ContinueOnError)
+
+	//add the default flags
+	bcmd.AddAppTxFlags(fsTxOrgMember)
+	bcmd.AddAppTxFlags(fsTxOrgChain)
+	bcmd.AddAppTxFlags(OrgMemberRemoveCmd.Flags())
+
+	fsTxOrgMember.String(trcmn.FlagRoles, "",
+		"Roles of the member in the format <ROLE1>,<ROLE2>, from submitter, approver, payer and admin")
+	fsTxOrgChain.StringArray(trcmn.FlagStep, nil,
+		"Repeatable approval step in order, in the format <NAME>:<ADDR1>,<ADDR2>, omit to remove the chain")
+
+	OrgMemberSetCmd.Flags().AddFlagSet(fsTxOrgMember)
+	OrgChainCmd.Flags().AddFlagSet(fsTxOrgChain)
+}
+
+func orgMemberSetCmd(cmd *cobra.Command, args []string) error {
+	return orgMemberCmd(args, invoicer.TBTxOrgMemberSet)
+}
+func orgMemberRemoveCmd(cmd *cobra.Command, args []string) error {
+	return orgMemberCmd(args, invoicer.TBTxOrgMemberRemove)
+}
+
+func orgMemberCmd(args []string, TBTx byte) error {
+	if len(args) != 2 {
+		return trcmn.ErrCmdReqArg("org, address")
+	}
+	address, err := hex.DecodeString(cmn.StripHex(args[1]))
+	if err != nil {
+		return errors.Wrap(err, "Member address must be hex")
+	}
+
+	var roles []string
+	if TBTx == invoicer.TBTxOrgMemberSet {
+		flagRoles := viper.GetString(trcmn.FlagRoles)
+		if len(flagRoles) == 0 {
+			return errors.New("Need the roles of the member, please specify through the flag --roles")
+		}
+		roles = strings.Split(flagRoles, ",")
+	}
+
+	return broadcastOrgTx(func(senderAddr []byte) []byte {
+		tx := types.TxOrgMember{
+			Org:        args[0],
+			SenderAddr: senderAddr,
+			Address:    address,
+			Roles:      roles,
+		}
+		return invoicer.MarshalWithTB(tx, TBTx)
+	})
+}
+
+func orgChainCmd(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("org")
+	}
+	stepFlags, err := cmd.Flags().GetStringArray(trcmn.FlagStep)
+	if err != nil {
+		return err
+	}
+	steps, err := parseApprovalSteps(stepFlags)
+	if err != nil {
+		return err
+	}
+
+	return broadcastOrgTx(func(senderAddr []byte) []byte {
+		tx := types.TxOrgChain{
+			Org:        args[0],
+			SenderAddr: senderAddr,
+			Steps:      steps,
+		}
+		return invoicer.MarshalWithTB(tx, invoicer.TBTxOrgChain)
+	})
+}
+
+// parseApprovalSteps parses approval steps in the format <NAME>:<ADDR1>,<ADDR2>
+func parseApprovalSteps(stepFlags []string) ([]types.ApprovalStep, error) {
+	var steps []types.ApprovalStep
+	for _, stepFlag := range stepFlags {
+		fields := strings.SplitN(stepFlag, ":", 2)
+		if len(fields) != 2 || len(fields[0]) == 0 || len(fields[1]) == 0 {
+			return nil, errors.Errorf("Bad approval step %v, must be in the format <NAME>:<ADDR1>,<ADDR2>", stepFlag)
+		}
+		step := types.ApprovalStep{Name: fields[0]}
+		for _, addrHex := range strings.Split(fields[1], ",") {
+			addr, err := hex.DecodeString(cmn.StripHex(addrHex))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Approver address %v of step %v must be hex", addrHex, step.Name)
+			}
+			step.Approvers = append(step.Approvers, addr)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// broadcastOrgTx broadcasts the org tx built for the signer's address
+func broadcastOrgTx(buildTx func(senderAddr []byte) []byte) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  buildTx(txInput.Address),
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
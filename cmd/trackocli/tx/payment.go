@@ -36,10 +36,15 @@ func init() {
 
 	fsTxPayment.String(trcmn.FlagIDs, "", "IDs to close during this transaction <id1>,<id2>,<id3>... ")
 	fsTxPayment.String(trcmn.FlagTransactionID, "", "Completed transaction ID")
-	fsTxPayment.String(trcmn.FlagPaid, "", "Payment amount in the format <decimal><currency> eg. 10.23usd")
+	fsTxPayment.String(trcmn.FlagPaid, "", "Payment amount in the format <decimal><currency> eg. 10.23BTC")
 	fsTxPayment.String(trcmn.FlagDate, "", "Date payment in the format YYYY-MM-DD eg. 2016-12-31 (default: today)")
 	fsTxPayment.String(trcmn.FlagDateRange, "",
 		"Autoselect IDs within the date range start:end, where start/end are in the format YYYY-MM-DD, or empty. ex. --date 1991-10-21:")
+	fsTxPayment.String(trcmn.FlagAllocation, "",
+		"Allocation of the payment between invoices: oldest, newest, proportional or explicit (default: oldest)")
+	fsTxPayment.String(trcmn.FlagAmounts, "",
+		"Amount paid to each of the IDs for the explicit allocation <amt1>,<amt2>,<amt3>... eg. 10USD,5.50USD")
+	fsTxPayment.String(trcmn.FlagOrg, "", "Organisation to pay on behalf of, as a member with the payer role")
 
 	PaymentCmd.Flags().AddFlagSet(fsTxPayment)
 }
@@ -105,7 +110,7 @@ func paymentTx(senderAddr []byte, receiver string) ([]byte, error) {
 			if err != nil {
 				return nil, err
 			}
-			ids = append([][]byte{id}, ids...)
+			ids = append(ids, id)
 		}
 	}
 
@@ -118,6 +123,21 @@ func paymentTx(senderAddr []byte, receiver string) ([]byte, error) {
 		return nil, err
 	}
 
+	//Get the amounts paid to each ID for an explicit allocation
+	var amounts []*types.AmtCurTime
+	if flagAmounts := viper.GetString(trcmn.FlagAmounts); len(flagAmounts) > 0 {
+		if len(flagIDs) == 0 {
+			return nil, errors.New("The amounts flag requires the IDs flag")
+		}
+		for _, amtStr := range strings.Split(flagAmounts, ",") {
+			amount, err := types.ParseAmtCurTime(amtStr, date)
+			if err != nil {
+				return nil, err
+			}
+			amounts = append(amounts, amount)
+		}
+	}
+
 	tx := types.TxPayment{
 		TransactionID: viper.GetString(trcmn.FlagTransactionID),
 		SenderAddr:    senderAddr,
@@ -125,6 +145,9 @@ func paymentTx(senderAddr []byte, receiver string) ([]byte, error) {
 		Receiver:      receiver,
 		Amt:           amt,
 		DateRange:     dateRange,
+		Allocation:    viper.GetString(trcmn.FlagAllocation),
+		Amounts:       amounts,
+		Org:           viper.GetString(trcmn.FlagOrg),
 	}
 
 	return invoicer.MarshalWithTB(tx, invoicer.TBTxPayment), nil
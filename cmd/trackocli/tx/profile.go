@@ -1,12 +1,17 @@
 package tx
 
 import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
 	txcmd "github.com/tendermint/light-client/commands/txs"
+	cmn "github.com/tendermint/tmlibs/common"
 
 	btypes "github.com/tendermint/basecoin/types"
 	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
@@ -33,6 +38,18 @@ var (
 		Short: "Deactivate and existing profile",
 		RunE:  profileDeactivateCmd,
 	}
+
+	ProfileReactivateCmd = &cobra.Command{
+		Use:   "profile-reactivate [name]",
+		Short: "Reactivate a deactivated profile",
+		RunE:  profileReactivateCmd,
+	}
+
+	ProfileRotateKeyCmd = &cobra.Command{
+		Use:   "profile-rotate-key [new-address]",
+		Short: "Move a profile from the signing key to a new address",
+		RunE:  profileRotateKeyCmd,
+	}
 )
 
 func init() {
@@ -41,12 +58,30 @@ func init() {
 	//add the default flags
 	bcmd.AddAppTxFlags(fsTxProfile)
 	bcmd.AddAppTxFlags(ProfileDeactivateCmd.Flags())
+	bcmd.AddAppTxFlags(ProfileReactivateCmd.Flags())
+	bcmd.AddAppTxFlags(ProfileRotateKeyCmd.Flags())
+
+	ProfileRotateKeyCmd.Flags().String(trcmn.FlagProfileName, "",
+		"Name of the profile, required when rotating the key of a signer (default: profile of the signing key)")
 
 	fsTxProfile.String(trcmn.FlagTo, "", "Who you're invoicing")
 	fsTxProfile.String(trcmn.FlagCur, "BTC", "Payment curreny accepted")
 	fsTxProfile.String(trcmn.FlagDepositInfo, "", "Default deposit information to be provided")
 	fsTxProfile.Int(trcmn.FlagDueDurationDays, 14,
 		"Default number of days until invoice is due from invoice submission")
+	fsTxProfile.String(trcmn.FlagTaxJurisdiction, "",
+		"Jurisdiction registered for tax as a country code with optional subdivision eg. DE or CA-ON (default: not registered)")
+	fsTxProfile.String(trcmn.FlagTaxID, "", "Tax registration ID eg. VAT or GST number")
+	fsTxProfile.String(trcmn.FlagTrusted, "",
+		"Profiles whose invoices are approved automatically in the format <NAME1>,<NAME2>, etc.")
+	fsTxProfile.String(trcmn.FlagSigners, "",
+		"Addresses (hex) signing for a multi-signature profile in the format <ADDR1>,<ADDR2>, etc.")
+	fsTxProfile.Int(trcmn.FlagThreshold, 0, "Number of signers required to approve actions of a multi-signature profile")
+	fsTxProfile.String(trcmn.FlagLimit, "",
+		"Invoices and payments above the limit need the threshold of signers eg. 1000USD (default: all)")
+	fsTxProfile.String(trcmn.FlagLateFees, "", "Late fee policy eg. \"fee 25USD, interest 1.5%/month, cap 10%\"")
+	fsTxProfile.String(trcmn.FlagTerms, "",
+		"Default payment terms eg. \"2/10 net 30\" for a 2% discount if paid within 10 days, due in 30 days")
 
 	ProfileOpenCmd.Flags().AddFlagSet(fsTxProfile)
 	ProfileEditCmd.Flags().AddFlagSet(fsTxProfile)
@@ -64,6 +99,50 @@ func profileDeactivateCmd(cmd *cobra.Command, args []string) error {
 	return profileCmd(cmd, args, invoicer.TBTxProfileDeactivate)
 }
 
+func profileReactivateCmd(cmd *cobra.Command, args []string) error {
+	return profileCmd(cmd, args, invoicer.TBTxProfileReactivate)
+}
+
+func profileRotateKeyCmd(cmd *cobra.Command, args []string) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("new-address")
+	}
+	newAddress, err := hex.DecodeString(cmn.StripHex(args[0]))
+	if err != nil {
+		return errors.Wrapf(err, "New address %v must be hex", args[0])
+	}
+
+	txRotate := types.TxProfileRotateKey{
+		Name:       viper.GetString(trcmn.FlagProfileName),
+		SenderAddr: txInput.Address,
+		NewAddress: newAddress,
+	}
+	data := invoicer.MarshalWithTB(txRotate, invoicer.TBTxProfileRotateKey)
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
+
 func profileCmd(cmd *cobra.Command, args []string, TBTx byte) error {
 
 	// Read the standard app-tx flags
@@ -74,14 +153,17 @@ func profileCmd(cmd *cobra.Command, args []string, TBTx byte) error {
 
 	// Retrieve the app-specific flags/args
 	var name string
-	if TBTx == invoicer.TBTxProfileOpen {
+	if TBTx == invoicer.TBTxProfileOpen || TBTx == invoicer.TBTxProfileReactivate {
 		if len(args) != 1 {
 			return trcmn.ErrCmdReqArg("name")
 		}
 		name = args[0]
 	}
 
-	data := profileTx(TBTx, txInput.Address, name)
+	data, err := profileTx(TBTx, txInput.Address, name)
+	if err != nil {
+		return err
+	}
 
 	// Create AppTx and broadcast
 	tx := &btypes.AppTx{
@@ -101,13 +183,31 @@ func profileCmd(cmd *cobra.Command, args []string, TBTx byte) error {
 }
 
 // profileTx Generates the tendermint TX used by the light and heavy client
-func profileTx(TBTx byte, address []byte, name string) []byte {
+func profileTx(TBTx byte, address []byte, name string) ([]byte, error) {
 	tx := types.TxProfile{
 		Address:         address,
 		Name:            name,
 		AcceptedCur:     viper.GetString(trcmn.FlagCur),
 		DepositInfo:     viper.GetString(trcmn.FlagDepositInfo),
 		DueDurationDays: viper.GetInt(trcmn.FlagDueDurationDays),
+		TaxJurisdiction: viper.GetString(trcmn.FlagTaxJurisdiction),
+		TaxID:           viper.GetString(trcmn.FlagTaxID),
+		Terms:           viper.GetString(trcmn.FlagTerms),
+		LateFees:        viper.GetString(trcmn.FlagLateFees),
+		Threshold:       viper.GetInt(trcmn.FlagThreshold),
+		Limit:           viper.GetString(trcmn.FlagLimit),
+	}
+	if trusted := viper.GetString(trcmn.FlagTrusted); len(trusted) > 0 {
+		tx.TrustedSenders = strings.Split(trusted, ",")
+	}
+	if signers := viper.GetString(trcmn.FlagSigners); len(signers) > 0 {
+		for _, signerHex := range strings.Split(signers, ",") {
+			signer, err := hex.DecodeString(cmn.StripHex(signerHex))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Signer address %v must be hex", signerHex)
+			}
+			tx.Signers = append(tx.Signers, signer)
+		}
 	}
-	return invoicer.MarshalWithTB(tx, TBTx)
+	return invoicer.MarshalWithTB(tx, TBTx), nil
 }
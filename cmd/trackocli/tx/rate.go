@@ -0,0 +1,125 @@
+package tx
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var RateCmd = &cobra.Command{
+	Use:   "rate [from] [to] [rate]",
+	Short: "Post the exchange rate of one unit of currency from in currency to (oracle only)",
+	RunE:  rateCmd,
+}
+
+func init() {
+	fsTxRate := flag.NewFlagSet("", flag.ContinueOnError)
+
+	//add the default flags
+	bcmd.AddAppTxFlags(fsTxRate)
+
+	fsTxRate.String(trcmn.FlagDate, "", "Date of the rate in the format YYYY-MM-DD eg. 2016-12-31 (default: today)")
+	fsTxRate.String(trcmn.FlagRateSource, "legacy",
+		"Source used to retrieve the rate when not provided: legacy (fixer/coindesk), a rate service url, or a json/csv fixture file")
+
+	RateCmd.Flags().AddFlagSet(fsTxRate)
+}
+
+func rateCmd(cmd *cobra.Command, args []string) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 2 && len(args) != 3 {
+		return trcmn.ErrCmdReqArg("from, to, rate")
+	}
+	var rate string
+	if len(args) == 3 {
+		rate = args[2]
+	}
+
+	data, err := rateTx(args[0], args[1], rate)
+	if err != nil {
+		return err
+	}
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
+
+// rateTx Generates the tendermint TX used by the light and heavy client
+func rateTx(from, to, rate string) ([]byte, error) {
+
+	//currencies must be registered, codes are case-insensitive
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	for _, cur := range []string{from, to} {
+		if _, err := types.GetCurrency(cur); err != nil {
+			return nil, err
+		}
+	}
+
+	date := time.Now()
+	if flagDate := viper.GetString(trcmn.FlagDate); len(flagDate) > 0 {
+		var err error
+		date, err = time.Parse(common.TimeLayout, flagDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//retrieve the rate from the source if it wasn't provided
+	if len(rate) == 0 {
+		provider, err := common.NewRateProvider(viper.GetString(trcmn.FlagRateSource))
+		if err != nil {
+			return nil, err
+		}
+		conv, _, err := common.ConversionRate(provider, from, to, date)
+		if err != nil {
+			return nil, err
+		}
+		rate = conv.String()
+	}
+	if _, err := decimal.NewFromString(rate); err != nil {
+		return nil, errors.Wrap(err, "Bad rate")
+	}
+
+	tx := types.TxRate{
+		From: from,
+		To:   to,
+		Rate: rate,
+		Date: date.Format(common.TimeLayout),
+	}
+
+	return invoicer.MarshalWithTB(tx, invoicer.TBTxRate), nil
+}
@@ -0,0 +1,114 @@
+package tx
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var (
+	PaymentReverseCmd = &cobra.Command{
+		Use:   "payment-reverse [tx-id]",
+		Short: "Reverse a payment you received whose transfer failed, reopening its invoices",
+		RunE:  paymentReverseCmd,
+	}
+
+	RefundCmd = &cobra.Command{
+		Use:   "refund [tx-id]",
+		Short: "Refund a payment you received, reopening its invoices",
+		RunE:  refundCmd,
+	}
+)
+
+func init() {
+	fsTxReverse := flag.NewFlagSet("", flag.ContinueOnError)
+
+	//add the default flags
+	bcmd.AddAppTxFlags(fsTxReverse)
+
+	fsTxReverse.String(trcmn.FlagReason, "", "Reason the payment is reversed")
+
+	fsTxRefund := flag.NewFlagSet("", flag.ContinueOnError)
+	fsTxRefund.String(trcmn.FlagRefundID, "", "Transaction ID of the transfer returning the funds")
+
+	PaymentReverseCmd.Flags().AddFlagSet(fsTxReverse)
+	RefundCmd.Flags().AddFlagSet(fsTxReverse)
+	RefundCmd.Flags().AddFlagSet(fsTxRefund)
+}
+
+func paymentReverseCmd(cmd *cobra.Command, args []string) error {
+	return reverseCmd(cmd, args, invoicer.TBTxPaymentReverse)
+}
+func refundCmd(cmd *cobra.Command, args []string) error {
+	return reverseCmd(cmd, args, invoicer.TBTxRefund)
+}
+
+func reverseCmd(cmd *cobra.Command, args []string, TBTx byte) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("tx-id")
+	}
+
+	data, err := reverseTx(txInput.Address, args[0], TBTx)
+	if err != nil {
+		return err
+	}
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
+
+// reverseTx Generates the tendermint TX used by the light and heavy client
+func reverseTx(senderAddr []byte, transactionID string, TBTx byte) ([]byte, error) {
+
+	reason := viper.GetString(trcmn.FlagReason)
+	if len(reason) == 0 {
+		return nil, errors.New("Need a reason to reverse, please specify through the flag --reason")
+	}
+	var refundID string
+	if TBTx == invoicer.TBTxRefund {
+		refundID = viper.GetString(trcmn.FlagRefundID)
+		if len(refundID) == 0 {
+			return nil, errors.New("Need the refund transaction ID, please specify through the flag --refund-id")
+		}
+	}
+
+	tx := types.TxPaymentReverse{
+		TransactionID: transactionID,
+		SenderAddr:    senderAddr,
+		RefundID:      refundID,
+		Reason:        reason,
+	}
+
+	return invoicer.MarshalWithTB(tx, TBTx), nil
+}
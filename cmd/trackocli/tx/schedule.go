@@ -0,0 +1,166 @@
+package tx
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	bcmd "github.com/tendermint/basecoin/cmd/basecli/commands"
+	btypes "github.com/tendermint/basecoin/types"
+	txcmd "github.com/tendermint/light-client/commands/txs"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	trcmn "github.com/tendermint/trackomatron/cmd/trackocli/common"
+	"github.com/tendermint/trackomatron/common"
+	"github.com/tendermint/trackomatron/plugins/invoicer"
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint
+var (
+	ScheduleOpenCmd = &cobra.Command{
+		Use:   "schedule-open [amount]",
+		Short: "Open a schedule of recurring contract invoices of amount <value><currency>",
+		RunE:  scheduleOpenCmd,
+	}
+
+	ScheduleCancelCmd = &cobra.Command{
+		Use:   "schedule-cancel [id]",
+		Short: "Cancel a schedule of recurring invoices so no further invoices are issued",
+		RunE:  scheduleCancelCmd,
+	}
+)
+
+func init() {
+	fsTxSchedule := flag.NewFlagSet("", flag.ContinueOnError)
+	fsTxScheduleCancel := flag.NewFlagSet("", flag.ContinueOnError)
+
+	//the apptx flags of schedule-open are included with the invoice flags
+	bcmd.AddAppTxFlags(fsTxScheduleCancel)
+
+	fsTxSchedule.String(trcmn.FlagCadence, "",
+		"Recurrence of the invoices: daily, weekly, fortnightly, monthly, quarterly, yearly, "+
+			"\"every <n> <days|weeks|months|years>\" or a cron expression eg. \"0 0 1 * *\"")
+	fsTxSchedule.String(trcmn.FlagStart, "", "Date of the first invoice in the format YYYY-MM-DD eg. 2016-12-31 (default: today)")
+	fsTxSchedule.String(trcmn.FlagEnd, "", "Date after which no invoices are issued in the format YYYY-MM-DD (default: no end)")
+	fsTxSchedule.Int(trcmn.FlagOccurrences, 0, "Number of invoices to issue, use 0 for no limit")
+
+	ScheduleOpenCmd.Flags().AddFlagSet(fsTxSchedule)
+	ScheduleCancelCmd.Flags().AddFlagSet(fsTxScheduleCancel)
+}
+
+func scheduleOpenCmd(cmd *cobra.Command, args []string) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	amountStr, lineItems, err := readInvoiceArgs(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := scheduleOpenTx(txInput.Address, amountStr, lineItems)
+	if err != nil {
+		return err
+	}
+
+	// Create AppTx and broadcast
+	tx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(tx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
+
+// scheduleOpenTx Generates the Tendermint tx
+func scheduleOpenTx(senderAddr []byte, amountStr string, lineItems []types.TxLineItem) ([]byte, error) {
+
+	cadence := viper.GetString(trcmn.FlagCadence)
+	if _, err := common.ParseCadence(cadence); err != nil {
+		return nil, err
+	}
+	for _, flagDate := range []string{trcmn.FlagStart, trcmn.FlagEnd} {
+		if date := viper.GetString(flagDate); len(date) > 0 {
+			if _, err := time.Parse(common.TimeLayout, date); err != nil {
+				return nil, err
+			}
+		}
+	}
+	occurrences := viper.GetInt(trcmn.FlagOccurrences)
+	if occurrences < 0 {
+		return nil, errors.New("Occurrences cannot be negative")
+	}
+
+	invoice, err := invoiceTx(invoicer.TBTxContractOpen, senderAddr, amountStr, lineItems)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := types.TxScheduleOpen{
+		Invoice:     invoice,
+		Cadence:     cadence,
+		Start:       viper.GetString(trcmn.FlagStart),
+		End:         viper.GetString(trcmn.FlagEnd),
+		Occurrences: occurrences,
+	}
+
+	return invoicer.MarshalWithTB(tx, invoicer.TBTxScheduleOpen), nil
+}
+
+func scheduleCancelCmd(cmd *cobra.Command, args []string) error {
+	// Read the standard app-tx flags
+	gas, fee, txInput, err := bcmd.ReadAppTxFlags()
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the app-specific flags/args
+	if len(args) != 1 {
+		return trcmn.ErrCmdReqArg("id")
+	}
+	if !cmn.IsHex(args[0]) {
+		return trcmn.ErrBadHexID
+	}
+	id, err := hex.DecodeString(cmn.StripHex(args[0]))
+	if err != nil {
+		return err
+	}
+
+	tx := types.TxScheduleCancel{
+		ID:         id,
+		SenderAddr: txInput.Address,
+	}
+	data := invoicer.MarshalWithTB(tx, invoicer.TBTxScheduleCancel)
+
+	// Create AppTx and broadcast
+	appTx := &btypes.AppTx{
+		Gas:   gas,
+		Fee:   fee,
+		Name:  invoicer.Name,
+		Input: txInput,
+		Data:  data,
+	}
+	res, err := bcmd.BroadcastAppTx(appTx)
+	if err != nil {
+		return err
+	}
+
+	// Output result
+	return txcmd.OutputTx(res)
+}
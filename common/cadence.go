@@ -0,0 +1,230 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cadence determines when a recurring event occurs
+type Cadence interface {
+
+	// Next returns the first occurrence strictly after the time after,
+	//   for a recurrence which begins at the time start
+	Next(start, after time.Time) time.Time
+}
+
+// maxCronDays bounds the search for the next occurrence of a cron cadence
+const maxCronDays = 366 * 5
+
+// ParseCadence parses a cadence which may be one of
+//   daily, weekly, fortnightly, monthly, quarterly or yearly
+//   every <n> <days|weeks|months|years>  eg. every 2 weeks
+//   a five field cron expression          eg. 0 9 1 * * for 9am on the 1st of each month
+// Interval cadences recur relative to their start, monthly cadences which
+//   start late in the month occur on the last day of shorter months
+func ParseCadence(cadence string) (Cadence, error) {
+	fields := strings.Fields(strings.ToLower(cadence))
+	switch {
+	case len(fields) == 1:
+		switch fields[0] {
+		case "daily":
+			return intervalCadence{days: 1}, nil
+		case "weekly":
+			return intervalCadence{days: 7}, nil
+		case "fortnightly":
+			return intervalCadence{days: 14}, nil
+		case "monthly":
+			return intervalCadence{months: 1}, nil
+		case "quarterly":
+			return intervalCadence{months: 3}, nil
+		case "yearly", "annually":
+			return intervalCadence{months: 12}, nil
+		}
+	case len(fields) == 3 && fields[0] == "every":
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return nil, errors.Errorf("bad cadence interval %v, must be a positive integer", fields[1])
+		}
+		switch strings.TrimSuffix(fields[2], "s") {
+		case "day":
+			return intervalCadence{days: n}, nil
+		case "week":
+			return intervalCadence{days: 7 * n}, nil
+		case "month":
+			return intervalCadence{months: n}, nil
+		case "year":
+			return intervalCadence{months: 12 * n}, nil
+		}
+	case len(fields) == 5:
+		return parseCron(fields)
+	}
+	return nil, errors.Errorf("bad cadence %v, must be daily, weekly, fortnightly, monthly, "+
+		"quarterly, yearly, every <n> <days|weeks|months|years> or a five field cron expression", cadence)
+}
+
+//________________________________________________________________________________
+
+// intervalCadence recurs at a fixed number of days or months from the start
+type intervalCadence struct {
+	days   int
+	months int
+}
+
+var _ Cadence = intervalCadence{}
+
+// occurrence returns the nth occurrence after start
+func (c intervalCadence) occurrence(start time.Time, n int) time.Time {
+	if c.months == 0 {
+		return start.AddDate(0, 0, n*c.days)
+	}
+
+	//clamp the day to the end of the month rather than overflowing
+	year, month, day := start.Date()
+	first := time.Date(year, month+time.Month(n*c.months), 1,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Next implements Cadence
+func (c intervalCadence) Next(start, after time.Time) time.Time {
+	if after.Before(start) {
+		return start
+	}
+
+	//estimate the number of occurrences elapsed then step forward
+	var n int
+	if c.months == 0 {
+		n = int(after.Sub(start) / (time.Duration(c.days) * 24 * time.Hour))
+	} else {
+		n = ((after.Year()-start.Year())*12 + int(after.Month()) - int(start.Month())) / c.months
+	}
+	if n > 0 {
+		n--
+	}
+	for !c.occurrence(start, n).After(after) {
+		n++
+	}
+	return c.occurrence(start, n)
+}
+
+//________________________________________________________________________________
+
+// cronCadence recurs at the times matching a cron expression
+type cronCadence struct {
+	minutes, hours, doms, months, dows map[int]bool
+	anyDom, anyDow                     bool
+}
+
+var _ Cadence = cronCadence{}
+
+func parseCron(fields []string) (Cadence, error) {
+	var c cronCadence
+	var err error
+	bounds := []struct {
+		field    *map[int]bool
+		min, max int
+		name     string
+	}{
+		{&c.minutes, 0, 59, "minute"},
+		{&c.hours, 0, 23, "hour"},
+		{&c.doms, 1, 31, "day of month"},
+		{&c.months, 1, 12, "month"},
+		{&c.dows, 0, 6, "day of week"},
+	}
+	for i, b := range bounds {
+		*b.field, err = parseCronField(fields[i], b.min, b.max)
+		if err != nil {
+			return nil, errors.WithMessage(err, "cron "+b.name)
+		}
+	}
+	c.anyDom, c.anyDow = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField parses a comma separated list of *, values,
+//   ranges (a-b) and steps (*/n or a-b/n) within the bounds
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	out := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, errors.Errorf("bad step in %v", part)
+			}
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			lo, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, errors.Errorf("bad value %v", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				hi, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, errors.Errorf("bad value %v", part)
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, errors.Errorf("%v is out of the range %v-%v", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			out[v] = true
+		}
+	}
+	return out, nil
+}
+
+// matchesDay follows the cron convention that when both the day of month and
+//   day of week are restricted a day matching either is an occurrence
+func (c cronCadence) matchesDay(t time.Time) bool {
+	dom, dow := c.doms[t.Day()], c.dows[int(t.Weekday())]
+	switch {
+	case c.anyDom && c.anyDow:
+		return true
+	case c.anyDom:
+		return dow
+	case c.anyDow:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next implements Cadence, the zero time is returned if there is no
+//   occurrence within five years
+func (c cronCadence) Next(start, after time.Time) time.Time {
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for i := 0; i < maxCronDays; i, day = i+1, day.AddDate(0, 0, 1) {
+		if !c.months[int(day.Month())] || !c.matchesDay(day) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if !c.hours[hour] {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				occurrence := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+				if c.minutes[minute] && !occurrence.Before(t) {
+					return occurrence
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCadence(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	jan31 := time.Date(2017, time.Month(1), 31, 0, 0, 0, 0, time.UTC)
+	day := func(year, month, day int) time.Time {
+		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var testCadence = []struct {
+		cadence  string
+		start    time.Time
+		after    time.Time
+		expected time.Time
+	}{
+		{"daily", jan31, jan31, day(2017, 2, 1)},
+		{"weekly", jan31, day(2017, 1, 1), jan31},
+		{"weekly", jan31, day(2017, 2, 8), day(2017, 2, 14)},
+		{"every 2 weeks", jan31, day(2017, 2, 14), day(2017, 2, 28)},
+		{"monthly", jan31, jan31, day(2017, 2, 28)},
+		{"monthly", jan31, day(2017, 2, 28), day(2017, 3, 31)},
+		{"Quarterly", jan31, day(2017, 3, 1), day(2017, 4, 30)},
+		{"every 1 year", jan31, day(2019, 6, 1), day(2020, 1, 31)},
+		{"0 9 1 * *", jan31, jan31, day(2017, 2, 1).Add(9 * time.Hour)},
+		{"30 17 * * 5", jan31, jan31, day(2017, 2, 3).Add(17*time.Hour + 30*time.Minute)},
+		{"0 0 1 */3 *", jan31, jan31, day(2017, 4, 1)},
+		{"0 0 29 2 *", jan31, jan31, day(2020, 2, 29)},
+	}
+	for _, test := range testCadence {
+		cadence, err := ParseCadence(test.cadence)
+		require.Nil(err, test.cadence)
+		next := cadence.Next(test.start, test.after)
+		assert.True(test.expected.Equal(next), "%v after %v expected %v got %v",
+			test.cadence, test.after, test.expected, next)
+	}
+
+	for _, bad := range []string{"", "hourly", "every 0 days", "every 2 fortnights",
+		"60 * * * *", "* * 0 * *", "* * * 13 *", "* * * * 7", "5-1 * * * *", "*/0 * * * *"} {
+		_, err := ParseCadence(bad)
+		assert.NotNil(err, bad)
+	}
+}
@@ -1,94 +1,137 @@
 package common
 
 import (
-	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sethgrid/pester"
 	"github.com/shopspring/decimal"
 
 	"github.com/tendermint/trackomatron/types"
 )
 
-// ConvertAmtCurTime converts a AmtCurTime variable to the same value with a different valuation denom
-func ConvertAmtCurTime(denomOut string, in *types.AmtCurTime) (out *types.AmtCurTime, err error) {
+// ConvertAmtCurTime converts a AmtCurTime variable to the same value with a different valuation denom,
+//   the conversion returned records the path and rates used so the conversion may be reproduced
+func ConvertAmtCurTime(provider RateProvider, denomOut string,
+	in *types.AmtCurTime) (out *types.AmtCurTime, conversion *types.Conversion, err error) {
 
 	inDec, err := decimal.NewFromString(in.Amount)
 	if err != nil {
-		return out, err
+		return
 	}
 
-	outDec, err := convert(in.CurTime.Cur, denomOut, inDec, in.CurTime.Date)
+	conversion = &types.Conversion{Path: []string{in.CurTime.Cur}}
+	if in.CurTime.Cur != denomOut {
+		rates, err := provider.Rates(in.CurTime.Date)
+		if err != nil {
+			return out, nil, errors.WithMessage(err,
+				fmt.Sprintf("denomIn %v, denomOut %v", in.CurTime.Cur, denomOut))
+		}
+		conversion, err = ConversionPath(rates, in.CurTime.Cur, denomOut)
+		if err != nil {
+			return out, nil, err
+		}
+	}
+
+	outDec, err := convert(conversion, inDec)
 	if err != nil {
-		return out, err
+		return out, nil, err
 	}
 
-	return &types.AmtCurTime{
-		CurTime: types.CurrencyTime{
-			Cur:  denomOut,
-			Date: in.CurTime.Date,
-		},
-		Amount: outDec.String(),
-	}, nil
+	//round to the precision of the output currency
+	out, err = types.NewAmtCurTime(denomOut, in.CurTime.Date, outDec)
+	if err != nil {
+		return out, nil, err
+	}
+	return out, conversion, nil
 }
 
-//XXX NON-DETERMINISTIC
-func convert(denomIn, denomOut string, amt decimal.Decimal, date time.Time) (out decimal.Decimal, err error) {
-	dateStr := date.Format("2006-01-02")
-	urlFiat2USD := fmt.Sprintf("http://api.fixer.io/%v?base=USD", dateStr)
-	urlUSD2BTC := fmt.Sprintf("http://api.coindesk.com/v1/bpi/historical/close.json?start=%v&end=%v", dateStr, dateStr)
+// ConversionRate retrieves the unrounded value of one unit of the denom from in the denom to
+func ConversionRate(provider RateProvider, from, to string, date time.Time) (rate decimal.Decimal,
+	conversion *types.Conversion, err error) {
 
-	//calculate the conversion factor
-	conv := decimal.New(1, 0)
-	if denomIn != "USD" {
-		multiplier, err := getRate(urlFiat2USD, "rates", denomIn)
-		if err != nil {
-			return out, errors.WithMessage(err,
-				fmt.Sprintf("denomIn %v, denomOut %v", denomIn, denomOut))
-		}
-		conv = conv.Mul(multiplier)
+	rates, err := provider.Rates(date)
+	if err != nil {
+		return
+	}
+	conversion, err = ConversionPath(rates, from, to)
+	if err != nil {
+		return
 	}
-	if denomOut == "BTC" {
-		multiplier, err := getRate(urlUSD2BTC, "bpi", dateStr)
+	rate, err = convert(conversion, decimal.New(1, 0))
+	return
+}
+
+// convert applies the rates along the conversion path to the amount
+func convert(conversion *types.Conversion, amt decimal.Decimal) (out decimal.Decimal, err error) {
+	if len(conversion.Rates) != len(conversion.Path)-1 {
+		return out, errors.New("conversion path and rates are inconsistent")
+	}
+
+	//accumulate the conversion factor as a fraction, divide last to retain precision
+	mul, div := decimal.New(1, 0), decimal.New(1, 0)
+	for i, rate := range conversion.Rates {
+		conv, err := decimal.NewFromString(rate.Rate)
 		if err != nil {
-			return out, errors.WithMessage(err,
-				fmt.Sprintf("denomIn %v, denomOut %v", denomIn, denomOut))
+			return out, err
 		}
-		conv = conv.Mul(multiplier)
-	} else if denomOut != "USD" {
-		divisor, err := getRate(urlFiat2USD, "rates", denomOut)
-		if err != nil {
-			return out, errors.WithMessage(err,
-				fmt.Sprintf("denomIn %v, denomOut %v", denomIn, denomOut))
+		from, to := conversion.Path[i], conversion.Path[i+1]
+		switch {
+		case rate.From == from && rate.To == to:
+			mul = mul.Mul(conv)
+		case rate.From == to && rate.To == from:
+			div = div.Mul(conv)
+		default:
+			return out, errors.Errorf("rate %v/%v does not match conversion step %v/%v",
+				rate.From, rate.To, from, to)
 		}
-		conv = conv.Div(divisor)
 	}
-
-	return amt.Div(conv), nil
+	return amt.Mul(mul).Div(div), nil
 }
 
-//XXX NON-DETERMINISTIC
-//Get the conversion decimal from an http call
-func getRate(url, index1, index2 string) (out decimal.Decimal, err error) {
-	var temp map[string]interface{}
+// ConversionPath finds the shortest path of rates which converts the denom from
+//   to the denom to. Each rate may be used in either direction, where multiple
+//   shortest paths exist the path through the alphabetically lowest denoms is used
+func ConversionPath(rates []types.ExchangeRate, from, to string) (*types.Conversion, error) {
 
-	resp, err := pester.Get(url)
-	if err != nil {
-		return out, err
+	//build the graph of denoms, each edge retains the rate it was created from
+	type edge struct {
+		denom string
+		rate  types.ExchangeRate
 	}
-	err = json.NewDecoder(resp.Body).Decode(&temp)
-	resp.Body.Close()
-	if err != nil {
-		return out, errors.WithMessage(err,
-			fmt.Sprintf("Bad json decode for url %v, index1 %v, index2 %v", url, index1, index2))
+	graph := make(map[string][]edge)
+	for _, rate := range rates {
+		graph[rate.From] = append(graph[rate.From], edge{rate.To, rate})
+		graph[rate.To] = append(graph[rate.To], edge{rate.From, rate})
+	}
+	for _, edges := range graph {
+		sort.SliceStable(edges, func(i, j int) bool { return edges[i].denom < edges[j].denom })
 	}
-	temp2 := temp[index1].(map[string]interface{})
 
-	if temp2[index2] == nil {
-		return out, errors.Errorf("Rate is nil using: url %v, index1 %v, index2 %v", url, index1, index2)
+	//breadth first search from the input denom
+	prev := map[string]edge{from: {}}
+	queue := []string{from}
+	for len(queue) > 0 && len(prev[to].denom) == 0 && from != to {
+		denom := queue[0]
+		queue = queue[1:]
+		for _, e := range graph[denom] {
+			if _, visited := prev[e.denom]; visited {
+				continue
+			}
+			prev[e.denom] = edge{denom, e.rate}
+			queue = append(queue, e.denom)
+		}
+	}
+	if _, found := prev[to]; !found {
+		return nil, errors.Errorf("no conversion available from %v to %v", from, to)
 	}
 
-	return decimal.NewFromFloat(temp2[index2].(float64)), nil
+	//walk back from the output denom to recover the path
+	conversion := &types.Conversion{Path: []string{to}}
+	for denom := to; denom != from; denom = prev[denom].denom {
+		conversion.Path = append([]string{prev[denom].denom}, conversion.Path...)
+		conversion.Rates = append([]types.ExchangeRate{prev[denom].rate}, conversion.Rates...)
+	}
+	return conversion, nil
 }
@@ -0,0 +1,267 @@
+package common
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sethgrid/pester"
+	"github.com/shopspring/decimal"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+// RateProvider supplies all the exchange rates quoted for a date
+type RateProvider interface {
+	Rates(date time.Time) ([]types.ExchangeRate, error)
+}
+
+// NewRateProvider creates a RateProvider from a source description:
+//   "legacy" (or empty) uses the fixer.io and coindesk.com apis,
+//   a http(s) url uses a HTTPRateProvider, anything else is treated
+//   as the path to a json or csv fixture file
+func NewRateProvider(source string) (RateProvider, error) {
+	switch {
+	case len(source) == 0, source == "legacy":
+		return LegacyRateProvider{}, nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return NewHTTPRateProvider(source), nil
+	default:
+		return LoadFixtureRateProvider(source)
+	}
+}
+
+//________________________________________________________________________________
+
+// rateRecord is the serialized form of an exchange rate used by
+//   fixture files and http rate services
+type rateRecord struct {
+	From string
+	To   string
+	Date string
+	Rate json.Number
+}
+
+func (r rateRecord) exchangeRate(date time.Time) (rate types.ExchangeRate, err error) {
+	if len(r.Date) > 0 {
+		date, err = time.Parse(TimeLayout, r.Date)
+		if err != nil {
+			return
+		}
+	}
+	if _, err = decimal.NewFromString(r.Rate.String()); err != nil {
+		return rate, errors.WithMessage(err, fmt.Sprintf("bad rate for %v/%v", r.From, r.To))
+	}
+	return types.ExchangeRate{
+		From: strings.ToUpper(r.From),
+		To:   strings.ToUpper(r.To),
+		Date: date,
+		Rate: r.Rate.String(),
+	}, nil
+}
+
+func decodeRateRecords(r io.Reader) (records []rateRecord, err error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	err = dec.Decode(&records)
+	return
+}
+
+//________________________________________________________________________________
+
+// FixtureRateProvider serves rates from a fixed set, ideal for testing
+//   and for running offline
+type FixtureRateProvider struct {
+	rates []types.ExchangeRate
+}
+
+var _ RateProvider = FixtureRateProvider{}
+
+// NewFixtureRateProvider creates a RateProvider from a set of rates
+func NewFixtureRateProvider(rates []types.ExchangeRate) FixtureRateProvider {
+	return FixtureRateProvider{rates}
+}
+
+// LoadFixtureRateProvider loads rates from a json or csv file. The json file
+//   contains a list of objects with the fields from, to, date, and rate, the
+//   csv file contains the same columns in the order from,to,date,rate
+func LoadFixtureRateProvider(path string) (provider FixtureRateProvider, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var records []rateRecord
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		records, err = decodeRateRecords(file)
+		if err != nil {
+			return provider, errors.WithMessage(err, "Bad json rate fixture "+path)
+		}
+	case ".csv":
+		lines, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			return provider, errors.WithMessage(err, "Bad csv rate fixture "+path)
+		}
+		for i, line := range lines {
+			if len(line) != 4 {
+				return provider, errors.Errorf("Bad csv rate fixture %v line %v, expected from,to,date,rate", path, i+1)
+			}
+			if i == 0 && strings.EqualFold(line[0], "from") {
+				continue //skip header
+			}
+			records = append(records, rateRecord{line[0], line[1], line[2], json.Number(strings.TrimSpace(line[3]))})
+		}
+	default:
+		return provider, errors.Errorf("Unknown rate fixture file type %v, must be .json or .csv", path)
+	}
+
+	for _, record := range records {
+		if len(record.Date) == 0 {
+			return provider, errors.Errorf("Rate fixture %v/%v is missing a date", record.From, record.To)
+		}
+		rate, err := record.exchangeRate(time.Time{})
+		if err != nil {
+			return provider, err
+		}
+		provider.rates = append(provider.rates, rate)
+	}
+	return provider, nil
+}
+
+// Rates implements RateProvider
+func (f FixtureRateProvider) Rates(date time.Time) (out []types.ExchangeRate, err error) {
+	day := date.Format(TimeLayout)
+	for _, rate := range f.rates {
+		if rate.Date.Format(TimeLayout) == day {
+			out = append(out, rate)
+		}
+	}
+	return out, nil
+}
+
+//________________________________________________________________________________
+
+// HTTPRateProvider retrieves rates from a configurable rate service.
+//   The url may contain the placeholder {date} which is replaced with the
+//   YYYY-MM-DD date, otherwise the date is added as the query parameter date.
+//   The service must respond with a json list of objects with the fields
+//   from, to, and rate
+type HTTPRateProvider struct {
+	url string
+}
+
+var _ RateProvider = HTTPRateProvider{}
+
+// NewHTTPRateProvider creates a RateProvider for the rate service at url
+func NewHTTPRateProvider(url string) HTTPRateProvider {
+	return HTTPRateProvider{url}
+}
+
+// Rates implements RateProvider
+func (h HTTPRateProvider) Rates(date time.Time) (out []types.ExchangeRate, err error) {
+	dateStr := date.Format(TimeLayout)
+
+	url := h.url
+	switch {
+	case strings.Contains(url, "{date}"):
+		url = strings.Replace(url, "{date}", dateStr, -1)
+	case strings.Contains(url, "?"):
+		url += "&date=" + dateStr
+	default:
+		url += "?date=" + dateStr
+	}
+
+	resp, err := pester.Get(url)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return out, errors.Errorf("Bad response from rate service %v: %v", url, resp.Status)
+	}
+	records, err := decodeRateRecords(resp.Body)
+	if err != nil {
+		return out, errors.WithMessage(err, "Bad json decode for url "+url)
+	}
+	for _, record := range records {
+		rate, err := record.exchangeRate(date)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, rate)
+	}
+	return out, nil
+}
+
+//________________________________________________________________________________
+
+// LegacyRateProvider retrieves fiat rates from api.fixer.io and the bitcoin
+//   price index from api.coindesk.com
+type LegacyRateProvider struct{}
+
+var _ RateProvider = LegacyRateProvider{}
+
+// Rates implements RateProvider
+func (LegacyRateProvider) Rates(date time.Time) (out []types.ExchangeRate, err error) {
+	dateStr := date.Format(TimeLayout)
+	urlFiat2USD := fmt.Sprintf("http://api.fixer.io/%v?base=USD", dateStr)
+	urlUSD2BTC := fmt.Sprintf("http://api.coindesk.com/v1/bpi/historical/close.json?start=%v&end=%v", dateStr, dateStr)
+
+	//fixer quotes the value of one USD in each currency
+	fiat, err := getRates(urlFiat2USD, "rates")
+	if err != nil {
+		return out, err
+	}
+	var curs []string
+	for cur := range fiat {
+		curs = append(curs, cur)
+	}
+	sort.Strings(curs)
+	for _, cur := range curs {
+		out = append(out, types.ExchangeRate{From: "USD", To: cur, Date: date, Rate: fiat[cur].String()})
+	}
+
+	//coindesk quotes the value of one BTC in USD
+	bpi, err := getRates(urlUSD2BTC, "bpi")
+	if err != nil {
+		return out, err
+	}
+	btc, ok := bpi[dateStr]
+	if !ok {
+		return out, errors.Errorf("Rate is nil using: url %v, index1 %v, index2 %v", urlUSD2BTC, "bpi", dateStr)
+	}
+	out = append(out, types.ExchangeRate{From: "BTC", To: "USD", Date: date, Rate: btc.String()})
+
+	return out, nil
+}
+
+//Get the map of rates from an http call
+func getRates(url, index string) (out map[string]json.Number, err error) {
+	var temp map[string]json.RawMessage
+
+	resp, err := pester.Get(url)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&temp)
+	if err == nil {
+		dec := json.NewDecoder(strings.NewReader(string(temp[index])))
+		dec.UseNumber()
+		err = dec.Decode(&out)
+	}
+	if err != nil {
+		return out, errors.WithMessage(err,
+			fmt.Sprintf("Bad json decode for url %v, index %v", url, index))
+	}
+	return out, nil
+}
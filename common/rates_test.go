@@ -0,0 +1,174 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestLoadFixtureRateProvider(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "rates")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	var testFixtures = []struct {
+		fileName string
+		contents string
+		errNil   bool
+	}{
+		{"rates.json", `[{"from":"btc","to":"USD","date":"2015-12-31","rate":430.5},
+			{"from":"USD","to":"CAD","date":"2016-12-31","rate":"1.34"}]`, true},
+		{"rates.csv", "from,to,date,rate\nbtc,USD,2015-12-31,430.5\nUSD,CAD,2016-12-31,1.34\n", true},
+		{"nodate.json", `[{"from":"BTC","to":"USD","rate":430.5}]`, false},
+		{"badrate.csv", "BTC,USD,2015-12-31,abc\n", false},
+		{"badcols.csv", "BTC,USD,430.5\n", false},
+		{"rates.txt", "BTC,USD,2015-12-31,430.5\n", false},
+	}
+
+	for _, test := range testFixtures {
+		filePath := path.Join(dir, test.fileName)
+		require.Nil(ioutil.WriteFile(filePath, []byte(test.contents), 0644))
+
+		provider, err := LoadFixtureRateProvider(filePath)
+		if !test.errNil {
+			assert.NotNil(err, test.fileName)
+			continue
+		}
+		require.Nil(err, test.fileName)
+
+		rates, err := provider.Rates(date)
+		require.Nil(err)
+		require.Len(rates, 1, test.fileName)
+		assert.Equal("BTC", rates[0].From)
+		assert.Equal("430.5", rates[0].Rate)
+		rates, err = provider.Rates(date2)
+		require.Nil(err)
+		require.Len(rates, 1, test.fileName)
+		assert.Equal("CAD", rates[0].To)
+	}
+}
+
+func TestHTTPRateProvider(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("date") != "2015-12-31" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `[{"from":"BTC","to":"USD","rate":430.5}]`)
+	}))
+	defer server.Close()
+
+	provider, err := NewRateProvider(server.URL)
+	require.Nil(err)
+	rates, err := provider.Rates(date)
+	require.Nil(err)
+	require.Len(rates, 1)
+	assert.Equal("BTC", rates[0].From)
+	assert.True(rates[0].Date.Equal(date))
+
+	_, err = provider.Rates(date2)
+	assert.NotNil(err)
+}
+
+func TestConvertAmtCurTime(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	provider := NewFixtureRateProvider([]types.ExchangeRate{
+		{From: "BTC", To: "USD", Date: date, Rate: "400"},
+		{From: "USD", To: "CAD", Date: date, Rate: "1.5"},
+	})
+
+	var testConv = []struct {
+		in       string
+		denomOut string
+		out      string
+		errNil   bool
+	}{
+		{"2BTC", "USD", "800", true},
+		{"200USD", "BTC", "0.5", true},
+		{"1BTC", "CAD", "600", true},
+		{"600CAD", "BTC", "1", true},
+		{"10CAD", "CAD", "10", true},
+		{"10EUR", "BTC", "", false},
+	}
+
+	for _, test := range testConv {
+		in, err := types.ParseAmtCurTime(test.in, date)
+		require.Nil(err)
+		out, _, err := ConvertAmtCurTime(provider, test.denomOut, in)
+		if !test.errNil {
+			assert.NotNil(err, test.in)
+			continue
+		}
+		require.Nil(err, test.in)
+		expected, err := types.ParseAmtCurTime(test.out+test.denomOut, date)
+		require.Nil(err)
+		eq, err := out.EQ(expected)
+		assert.Nil(err)
+		assert.True(eq, "%v to %v got %v", test.in, test.denomOut, out.Amount)
+	}
+}
+
+func TestConversionPath(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rates := []types.ExchangeRate{
+		{From: "EUR", To: "USD", Date: date, Rate: "1.1"},
+		{From: "BTC", To: "USD", Date: date, Rate: "400"},
+		{From: "ETH", To: "BTC", Date: date, Rate: "0.05"},
+		{From: "ATOM", To: "ETH", Date: date, Rate: "0.01"},
+		{From: "USDT", To: "USD", Date: date, Rate: "1"},
+		{From: "ETH", To: "USDT", Date: date, Rate: "20"},
+	}
+
+	var testPaths = []struct {
+		from, to string
+		path     []string
+		errNil   bool
+	}{
+		{"EUR", "USD", []string{"EUR", "USD"}, true},
+		{"USD", "EUR", []string{"USD", "EUR"}, true},
+		{"EUR", "BTC", []string{"EUR", "USD", "BTC"}, true},
+		{"EUR", "ETH", []string{"EUR", "USD", "BTC", "ETH"}, true}, //BTC before USDT
+		{"ATOM", "USDT", []string{"ATOM", "ETH", "USDT"}, true},
+		{"EUR", "CAD", nil, false},
+	}
+	for _, test := range testPaths {
+		conversion, err := ConversionPath(rates, test.from, test.to)
+		if !test.errNil {
+			assert.NotNil(err)
+			continue
+		}
+		require.Nil(err, test.from+test.to)
+		assert.Equal(test.path, conversion.Path)
+		assert.Len(conversion.Rates, len(test.path)-1)
+	}
+
+	//the recorded conversion reproduces the converted amount
+	provider := NewFixtureRateProvider(rates)
+	in, err := types.ParseAmtCurTime("1100EUR", date)
+	require.Nil(err)
+	out, conversion, err := ConvertAmtCurTime(provider, "ETH", in)
+	require.Nil(err)
+	assert.Equal("60.5", out.Amount)
+	reproduced, _, err := ConvertAmtCurTime(NewFixtureRateProvider(conversion.Rates), "ETH", in)
+	require.Nil(err)
+	assert.Equal(out.Amount, reproduced.Amount)
+}
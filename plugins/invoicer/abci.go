@@ -2,9 +2,13 @@
 package invoicer
 
 import (
+	"encoding/hex"
+	"time"
+
 	abci "github.com/tendermint/abci/types"
 	"github.com/tendermint/basecoin/state"
 	btypes "github.com/tendermint/basecoin/types"
+	cmn "github.com/tendermint/tmlibs/common"
 )
 
 // Name of this plugin
@@ -25,6 +29,19 @@ func (inv *Invoicer) Name() string {
 }
 
 func (inv *Invoicer) SetOption(store btypes.KVStore, key string, value string) (log string) {
+	switch key {
+	case "oracle":
+		//register an address (hex) which is authorized to post exchange rates
+		address, err := hex.DecodeString(cmn.StripHex(value))
+		if err != nil {
+			return "Error decoding oracle address: " + err.Error()
+		}
+		err = addOracle(store, address)
+		if err != nil {
+			return "Error adding oracle: " + err.Error()
+		}
+		return "Success"
+	}
 	return ""
 }
 
@@ -45,14 +62,48 @@ func (inv *Invoicer) RunTx(store btypes.KVStore, ctx btypes.CallContext, txBytes
 		return abci.ErrBaseEncodingError.AppendLog("Error decoding tx: no tx bytes")
 	}
 
+	return runTx(store, ctx.CallerAddress, txBytes)
+}
+
+// runTx sends the tx signed by the caller to the transaction function of its type
+func runTx(store btypes.KVStore, callerAddr []byte, txBytes []byte) abci.Result {
+
 	//Note that the zero position of txBytes contains the type-byte for the tx type
 	switch txBytes[0] {
 	case TBTxProfileOpen, TBTxProfileEdit, TBTxProfileDeactivate:
-		return runTxProfile(store, txBytes)
+		return runTxProfile(store, callerAddr, txBytes)
+	case TBTxProfileReactivate:
+		return runTxProfileReactivate(store, callerAddr, txBytes)
+	case TBTxProfileRotateKey:
+		return runTxProfileRotateKey(store, callerAddr, txBytes)
 	case TBTxContractOpen, TBTxContractEdit, TBTxExpenseOpen, TBTxExpenseEdit:
-		return runTxInvoice(store, txBytes)
+		return runTxInvoice(store, callerAddr, txBytes)
+	case TBTxContractVoid, TBTxExpenseVoid:
+		return runTxInvoiceVoid(store, callerAddr, txBytes)
+	case TBTxInvoiceApprove, TBTxInvoiceReject:
+		return runTxInvoiceApproval(store, callerAddr, txBytes)
+	case TBTxInvoiceDispute:
+		return runTxInvoiceDispute(store, callerAddr, txBytes)
+	case TBTxInvoiceRespond:
+		return runTxInvoiceRespond(store, callerAddr, txBytes)
+	case TBTxOrgMemberSet, TBTxOrgMemberRemove:
+		return runTxOrgMember(store, callerAddr, txBytes)
+	case TBTxOrgChain:
+		return runTxOrgChain(store, callerAddr, txBytes)
+	case TBTxCreditNote:
+		return runTxCreditNote(store, callerAddr, txBytes)
 	case TBTxPayment:
-		return runTxPayment(store, txBytes)
+		return runTxPayment(store, callerAddr, txBytes)
+	case TBTxPaymentReverse, TBTxRefund:
+		return runTxPaymentReverse(store, callerAddr, txBytes)
+	case TBTxRate:
+		return runTxRate(store, callerAddr, txBytes)
+	case TBTxScheduleOpen:
+		return runTxScheduleOpen(store, callerAddr, txBytes)
+	case TBTxScheduleCancel:
+		return runTxScheduleCancel(store, callerAddr, txBytes)
+	case TBTxActionSign:
+		return runTxActionSign(store, callerAddr, txBytes)
 	default:
 		return abci.ErrBaseEncodingError.AppendLog("Error decoding tx: bad prepended bytes")
 	}
@@ -62,8 +113,21 @@ func (inv *Invoicer) InitChain(store btypes.KVStore, vals []*abci.Validator) {
 }
 
 func (inv *Invoicer) BeginBlock(store btypes.KVStore, hash []byte, header *abci.Header) {
+	//record the block time so all validators use the same time within the block
+	setBlockTime(store, time.Unix(int64(header.Time), 0).UTC())
 }
 
 func (inv *Invoicer) EndBlock(store btypes.KVStore, height uint64) (res abci.ResponseEndBlock) {
+	//issue the recurring invoices which have fallen due
+	err := issueSchedules(store)
+	if err != nil {
+		panic(err) //state is inconsistent, halt rather than diverge
+	}
+
+	//accrue late charges on overdue invoices as of the block time
+	err = accrueLateFees(store)
+	if err != nil {
+		panic(err) //state is inconsistent, halt rather than diverge
+	}
 	return
 }
@@ -0,0 +1,147 @@
+package invoicer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	types "github.com/tendermint/trackomatron/types"
+)
+
+// validateExplicitAmounts checks the amounts of an explicit allocation,
+//   one amount is required for each invoice ID and they must sum to the payment
+func validateExplicitAmounts(payment *types.Payment, amounts []*types.AmtCurTime) error {
+	if len(amounts) != len(payment.InvoiceIDs) {
+		return errors.Errorf("explicit allocation requires an amount for each of the %v invoice IDs, found %v",
+			len(payment.InvoiceIDs), len(amounts))
+	}
+	var sum *types.AmtCurTime
+	for _, amt := range amounts {
+		if err := amt.Validate(); err != nil {
+			return err
+		}
+		var err error
+		sum, err = sum.Add(amt)
+		if err != nil {
+			return err
+		}
+	}
+	eq, err := sum.EQ(payment.PaymentCurTime)
+	if err != nil {
+		return err
+	}
+	if !eq {
+		return errors.Errorf("explicit allocation amounts sum to %v%v but the payment is %v%v",
+			sum.Amount, sum.CurTime.Cur, payment.PaymentCurTime.Amount, payment.PaymentCurTime.CurTime.Cur)
+	}
+	return nil
+}
+
+// allocatePayment pays the open invoices from the fund of the payment according
+//   to its allocation strategy on the block time, the portion allocated to each
+//   invoice is recorded on the payment. The fund is converted to each invoice's
+//   payable currency at the rate for that currency and must not exceed the total
+//   unpaid, for the explicit strategy the amounts correspond to the invoices.
+func allocatePayment(payment *types.Payment, invoices []*types.Invoice, fund *types.AmtCurTime,
+	rates map[string]string, amounts []*types.AmtCurTime, blockTime time.Time) error {
+
+	unpaidAt := func(invoice *types.Invoice) (*types.AmtCurTime, error) {
+		ctx := invoice.GetCtx()
+		return ctx.UnpaidAt(fund.CurTime.Cur, rates[ctx.Payable.CurTime.Cur], blockTime)
+	}
+
+	pay := func(invoice *types.Invoice, amt *types.AmtCurTime) (*types.AmtCurTime, error) {
+		ctx := invoice.GetCtx()
+		n := len(ctx.Allocations)
+		leftover, err := ctx.PayAt(payment.TransactionID, amt, rates[ctx.Payable.CurTime.Cur], blockTime)
+		if err != nil {
+			return nil, err
+		}
+		if len(ctx.Allocations) > n {
+			payment.Allocated = append(payment.Allocated, types.InvoiceAllocation{
+				InvoiceID: invoice.GetID(),
+				Amount:    ctx.Allocations[n].Amount,
+			})
+		}
+		return leftover, nil
+	}
+
+	if payment.Allocation == types.AllocateExplicit {
+		for i, invoice := range invoices {
+			unpaid, err := unpaidAt(invoice)
+			if err != nil {
+				return err
+			}
+			gt, err := amounts[i].GT(unpaid)
+			if err != nil {
+				return err
+			}
+			if gt {
+				return errors.Errorf("cannot allocate %v%v to invoice ID %x which has %v%v unpaid",
+					amounts[i].Amount, amounts[i].CurTime.Cur, invoice.GetID(), unpaid.Amount, unpaid.CurTime.Cur)
+			}
+			if !invoice.GetCtx().Open || amounts[i].Amount == "0" {
+				continue
+			}
+			if _, err := pay(invoice, amounts[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	//invoices closed by credit cannot receive a payment
+	var open []*types.Invoice
+	for _, invoice := range invoices {
+		if invoice.GetCtx().Open {
+			open = append(open, invoice)
+		}
+	}
+
+	switch payment.Allocation {
+	case types.AllocateProportional:
+		var unpaid []*types.AmtCurTime
+		for _, invoice := range open {
+			u, err := unpaidAt(invoice)
+			if err != nil {
+				return err
+			}
+			unpaid = append(unpaid, u)
+		}
+		if len(open) == 0 {
+			return nil
+		}
+		shares, err := types.SplitProportional(fund, unpaid)
+		if err != nil {
+			return err
+		}
+		for i, invoice := range open {
+			if shares[i].Amount == "0" {
+				continue
+			}
+			if _, err := pay(invoice, shares[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case types.AllocateNewest:
+		sort.SliceStable(open, func(i, j int) bool {
+			return open[i].GetCtx().Due.After(open[j].GetCtx().Due)
+		})
+	default:
+		sort.SliceStable(open, func(i, j int) bool {
+			return open[i].GetCtx().Due.Before(open[j].GetCtx().Due)
+		})
+	}
+
+	bal := fund
+	for _, invoice := range open {
+		var err error
+		bal, err = pay(invoice, bal)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestAllocation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar()
+
+	openInvoice := func(amt, notes, due string) []byte {
+		return f.openInvoiceTx(types.TxInvoice{Amount: amt, Notes: notes, DueDate: due})
+	}
+	pay := func(txID, amt, allocation string, amounts []string, ids ...[]byte) abci.Result {
+		tx := types.TxPayment{TransactionID: txID, IDs: ids, Amt: f.amt(amt), Allocation: allocation}
+		for _, a := range amounts {
+			tx.Amounts = append(tx.Amounts, f.amt(a))
+		}
+		return f.runPayment(tx)
+	}
+	paid := f.paid
+	allocated := func(txID string) (out []string) {
+		payment, err := getPayment(f.store, []byte(txID))
+		require.Nil(err)
+		for _, a := range payment.Allocated {
+			out = append(out, a.Amount.Amount)
+		}
+		return out
+	}
+
+	//the invoice due soonest is paid first regardless of the order of the IDs
+	late := openInvoice("100USD", "late", "2017-03-01")
+	soon := openInvoice("100USD", "soon", "2017-02-10")
+	res := pay("oldest", "150USD", "", nil, late, soon)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("50", paid(late))
+	assert.Equal("100", paid(soon))
+	payment, err := getPayment(f.store, []byte("oldest"))
+	require.Nil(err)
+	assert.Equal(types.AllocateOldest, payment.Allocation)
+	assert.Equal([]string{"100", "50"}, allocated("oldest"))
+	assert.Equal(soon, payment.Allocated[0].InvoiceID)
+
+	//newest pays the invoice due latest first
+	a := openInvoice("100USD", "a", "2017-02-10")
+	b := openInvoice("100USD", "b", "2017-03-01")
+	res = pay("newest", "120USD", types.AllocateNewest, nil, a, b)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("20", paid(a))
+	assert.Equal("100", paid(b))
+
+	//proportional splits by the unpaid amount, rounding remainders are not lost
+	c := openInvoice("100USD", "c", "")
+	d := openInvoice("200USD", "d", "")
+	e := openInvoice("100USD", "e", "")
+	res = pay("proportional", "100USD", types.AllocateProportional, nil, c, d, e)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("25", paid(c))
+	assert.Equal("50", paid(d))
+	assert.Equal("25", paid(e))
+	res = pay("thirds", "0.10USD", types.AllocateProportional, nil, c, e)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal([]string{"0.05", "0.05"}, allocated("thirds"))
+
+	//explicit amounts must sum to the payment and not exceed the unpaid amounts
+	g := openInvoice("100USD", "g", "")
+	h := openInvoice("100USD", "h", "")
+	assert.True(pay("bad1", "50USD", types.AllocateExplicit, []string{"10USD", "30USD"}, g, h).IsErr())
+	assert.True(pay("bad2", "110USD", types.AllocateExplicit, []string{"101USD", "9USD"}, g, h).IsErr())
+	assert.True(pay("bad3", "10USD", types.AllocateExplicit, []string{"10USD"}, g, h).IsErr())
+	assert.True(pay("bad4", "10USD", "cheapest", nil, g, h).IsErr())
+	res = pay("explicit", "50USD", types.AllocateExplicit, []string{"10USD", "40USD"}, g, h)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("10", paid(g))
+	assert.Equal("40", paid(h))
+	assert.Equal([]string{"10", "40"}, allocated("explicit"))
+
+	//an invoice may only be given once
+	assert.True(pay("twice", "20USD", "", nil, g, g).IsErr())
+	assert.Equal("10", paid(g))
+}
@@ -0,0 +1,86 @@
+package invoicer
+
+import (
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func runTxInvoiceApproval(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	tb := txBytes[0]
+
+	// Decode tx
+	var tx = new(types.TxInvoiceApproval)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+
+	//only the receiver of the invoice, or its approvers, may approve or reject it
+	invoice, err := getInvoice(store, tx.ID)
+	if err != nil {
+		return abciErrInvoiceMissing
+	}
+	receiver, err := getProfile(store, invoice.GetCtx().Receiver)
+	if err != nil {
+		return abciErrNoReceiver
+	}
+	switch {
+	case isCreditNote(invoice):
+		return abciErrCreditNote
+	case invoice.GetCtx().Voided:
+		return abciErrInvoiceVoided
+	case !receiver.HasRole(callerAddr, types.RoleApprover):
+		return abciErrNotOwner("invoice was sent to another profile")
+	}
+
+	//the signers of a multi-signature receiver decide large invoices together,
+	//  approvers holding the role as members decide alone
+	if receiver.Controls(callerAddr) && receiver.RequiresSignatures(invoice.GetCtx().Invoiced) {
+		ready, res := collectSignatures(store, &receiver, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+
+	if tb == TBTxInvoiceReject {
+		err = invoice.GetCtx().Reject(tx.Comment)
+		if err != nil {
+			return abci.ErrUnauthorized.AppendLog("Error rejecting invoice: " + err.Error())
+		}
+		store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
+		return abci.OK
+	}
+
+	//invoices with an approval chain are approved one step at a time
+	if len(invoice.GetCtx().Chain) > 0 {
+		err = invoice.GetCtx().ApproveStep(callerAddr, blockTime, tx.Comment)
+	} else {
+		err = invoice.GetCtx().Approve(tx.Comment)
+	}
+	if err != nil {
+		return abci.ErrUnauthorized.AppendLog("Error approving invoice: " + err.Error())
+	}
+
+	//credit held from overpayments is applied once the invoice is payable
+	if invoice.GetCtx().Approved() {
+		if err := applyBalance(store, invoice, blockTime); err != nil {
+			return abciErrDecimal(err)
+		}
+	}
+	store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
+	return abci.OK
+}
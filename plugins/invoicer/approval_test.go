@@ -0,0 +1,71 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestInvoiceApproval(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("baz")
+	sender, receiver, trusted := f.sender, f.receiver, []byte("trusted")
+	f.openProfile(trusted, types.TxProfile{Name: "baz", AcceptedCur: "USD", DueDurationDays: 14})
+
+	openInvoice := func(addr []byte, notes string) []byte {
+		return f.openInvoiceTx(types.TxInvoice{Amount: "100USD", SenderAddr: addr, Notes: notes})
+	}
+	decide := func(addr, id []byte, tb byte, comment string) abci.Result {
+		tx := types.TxInvoiceApproval{ID: id, SenderAddr: addr, Comment: comment}
+		return runTxInvoiceApproval(f.store, addr, MarshalWithTB(tx, tb))
+	}
+	pay := func(txID, to, amt string, ids ...[]byte) abci.Result {
+		return f.runPayment(types.TxPayment{TransactionID: txID, IDs: ids, Receiver: to, Amt: f.amt(amt)})
+	}
+	ctx := f.ctx
+
+	//invoices start pending and are not selected automatically
+	first := openInvoice(sender, "first")
+	assert.Equal(types.ApprovalPending, ctx(first).Approval)
+	assert.True(pay("tx1", "foo", "100USD").IsErr())
+
+	//only the receiver may approve
+	assert.Equal(CodeTypeNotOwner, decide(sender, first, TBTxInvoiceApprove, "").Code)
+	res := decide(receiver, first, TBTxInvoiceApprove, "looks good")
+	require.True(res.IsOK(), res.Log)
+	assert.Equal(types.ApprovalApproved, ctx(first).Approval)
+	assert.Equal("looks good", ctx(first).Comment)
+	assert.True(decide(receiver, first, TBTxInvoiceApprove, "").IsErr())
+	assert.True(decide(receiver, first, TBTxInvoiceReject, "").IsErr())
+
+	res = pay("tx1", "foo", "100USD")
+	require.True(res.IsOK(), res.Log)
+	assert.False(ctx(first).Open)
+
+	//rejected invoices cannot be paid
+	second := openInvoice(sender, "second")
+	res = decide(receiver, second, TBTxInvoiceReject, "not ordered")
+	require.True(res.IsOK(), res.Log)
+	assert.Equal(types.ApprovalRejected, ctx(second).Approval)
+	assert.Equal("not ordered", ctx(second).Comment)
+	assert.Equal(abciErrInvoiceRejected, pay("tx2", "foo", "100USD", second))
+
+	//neither rejected nor paid invoices are selected automatically
+	assert.True(pay("tx2", "foo", "100USD").IsErr())
+	assert.Empty(ctx(second).Allocations)
+	assert.Len(ctx(first).Allocations, 1)
+
+	//invoices from trusted senders are approved automatically
+	third := openInvoice(trusted, "third")
+	assert.True(ctx(third).Approved())
+	res = pay("tx3", "baz", "100USD")
+	require.True(res.IsOK(), res.Log)
+	assert.False(ctx(third).Open)
+}
@@ -0,0 +1,51 @@
+package invoicer
+
+import (
+	"time"
+
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+// addBalance adds the excess of a payment from the sender to the
+//   receiver to the credit balance held between them
+func addBalance(store btypes.KVStore, sender, receiver string, excess *types.AmtCurTime) error {
+	balance, err := getBalance(store, sender, receiver)
+	switch {
+	case err == errStateNotFound:
+		balance = types.Balance{Sender: sender, Receiver: receiver}
+	case err != nil:
+		return err
+	}
+	balance.Credit, err = balance.Credit.Add(excess)
+	if err != nil {
+		return err
+	}
+	store.Set(BalanceKey(sender, receiver), wire.BinaryBytes(balance))
+	return nil
+}
+
+// applyBalance pays a new invoice from the credit balance held by its sender
+//   from overpayments by its receiver, the credit must be in the payable currency
+func applyBalance(store btypes.KVStore, invoice types.Invoice, blockTime time.Time) error {
+	ctx := invoice.GetCtx()
+	balance, err := getBalance(store, ctx.Receiver, ctx.Sender)
+	switch {
+	case err == errStateNotFound:
+		return nil
+	case err != nil:
+		return err
+	}
+	if balance.Credit == nil || balance.Credit.Amount == "0" ||
+		balance.Credit.CurTime.Cur != ctx.Payable.CurTime.Cur {
+		return nil
+	}
+	balance.Credit, err = ctx.Pay(types.BalanceTransactionID, balance.Credit, blockTime)
+	if err != nil {
+		return err
+	}
+	store.Set(BalanceKey(balance.Sender, balance.Receiver), wire.BinaryBytes(balance))
+	return nil
+}
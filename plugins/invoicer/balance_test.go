@@ -0,0 +1,51 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestOverpaymentBalance(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("foo")
+
+	credit := func() string {
+		balance, err := getBalance(f.store, "bar", "foo")
+		require.Nil(err)
+		return balance.Credit.Amount
+	}
+
+	//the excess of an overpayment is kept as credit
+	first := f.openInvoice("100USD", "first")
+	res := f.pay("tx1", "100.05USD", first)
+	require.True(res.IsOK(), res.Log)
+	assert.False(f.ctx(first).Open)
+	assert.Equal("0.05", credit())
+	payment, err := getPayment(f.store, []byte("tx1"))
+	require.Nil(err)
+	assert.Equal("0.05", payment.Excess.Amount)
+
+	//further excess accumulates
+	second := f.openInvoice("50USD", "second")
+	res = f.pay("tx2", "51USD", second)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("1.05", credit())
+
+	//the credit pays the next invoice between the parties
+	third := f.openInvoice("20USD", "third")
+	assert.Equal("1.05", f.paid(third))
+	assert.Equal(types.BalanceTransactionID, f.ctx(third).Allocations[0].TransactionID)
+	assert.True(f.ctx(third).Open)
+	assert.Equal("0", credit())
+
+	//the credit is not held in the other direction
+	_, err = getBalance(f.store, "foo", "bar")
+	assert.Equal(errStateNotFound, err)
+}
@@ -0,0 +1,192 @@
+package invoicer
+
+import (
+	"time"
+
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/common"
+	"github.com/tendermint/trackomatron/types"
+)
+
+func isCreditNote(invoice types.Invoice) bool {
+	_, ok := invoice.Unwrap().(*types.CreditNote)
+	return ok
+}
+
+func runTxCreditNote(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	// Decode tx
+	var tx = new(types.TxCreditNote)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	//get the sender's profile from the signer's address
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
+	if err != nil {
+		return abciErrNoSender
+	}
+
+	//the credit note must reference invoices sent by the sender to a single receiver
+	if len(tx.InvoiceIDs) == 0 {
+		return abci.ErrInternalError.AppendLog("credit note must reference the invoices it credits")
+	}
+	var invoices []types.Invoice
+	var invoiced *types.AmtCurTime
+	for _, id := range tx.InvoiceIDs {
+		invoice, err := getInvoice(store, id)
+		if err != nil {
+			return abciErrInvoiceMissing
+		}
+		ctx := invoice.GetCtx()
+		switch {
+		case isCreditNote(invoice):
+			return abci.ErrInternalError.AppendLog("credit note cannot reference another credit note")
+		case ctx.Sender != profile.Name:
+			return abciErrNotOwner("invoice was sent by another profile")
+		case ctx.Voided:
+			return abci.ErrInternalError.AppendLog("credit note cannot reference a voided invoice")
+		case len(invoices) > 0 && ctx.Receiver != invoices[0].GetCtx().Receiver:
+			return abci.ErrInternalError.AppendLog("credit note invoices must share a receiver")
+		case len(invoices) > 0 && ctx.AcceptedCur != invoices[0].GetCtx().AcceptedCur:
+			return abci.ErrInternalError.AppendLog("credit note invoices must share an accepted currency")
+		}
+		invoiced, err = invoiced.Add(ctx.Payable)
+		if err != nil {
+			return abciErrDecimal(err)
+		}
+		invoices = append(invoices, invoice)
+	}
+	receiver := invoices[0].GetCtx().Receiver
+	accCur := invoices[0].GetCtx().AcceptedCur
+
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	date := blockTime
+	if len(tx.Date) > 0 {
+		date, err = time.Parse(common.TimeLayout, tx.Date)
+		if err != nil {
+			return abciErrInternal(err)
+		}
+	}
+
+	//the credit is converted to the currency payable on the invoices
+	amt, err := types.ParseAmtCurTime(tx.Amount, date)
+	if err != nil {
+		return abciErrBadAmount(err)
+	}
+	payable, conversion, err := convertAmtCurTime(store, accCur, amt)
+	if err != nil {
+		return abciErrNoRate(err)
+	}
+	if err := payable.Validate(); err != nil {
+		return abciErrBadAmount(err)
+	}
+	if payable.Amount == "0" {
+		return abci.ErrInternalError.AppendLog("credit note amount must be positive")
+	}
+	if gt, err := payable.GT(invoiced); err != nil || gt {
+		return abci.ErrInternalError.AppendLog("credit note cannot exceed the invoices it credits")
+	}
+
+	//large credit notes from a multi-signature profile need the threshold of signers
+	if profile.RequiresSignatures(amt) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	note := types.NewCreditNote(nil, profile.Name, receiver, tx.Notes, accCur,
+		date, amt, payable, tx.InvoiceIDs)
+	note.Ctx.Conversion = conversion
+	note.SetID()
+	if _, err := getInvoice(store, note.ID); err == nil {
+		return abciErrDupInvoice
+	}
+
+	//apply the credit to the referenced invoices, any remainder stays as credit
+	for _, invoice := range invoices {
+		err = note.ApplyTo(invoice.GetID(), invoice.GetCtx(), blockTime)
+		if err != nil {
+			return abciErrDecimal(err)
+		}
+		store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
+	}
+	store.Set(InvoiceKey(note.ID), wire.BinaryBytes(note.Wrap()))
+
+	list, err := getListBytes(store, ListInvoiceKey())
+	if err != nil {
+		return abciErrGetInvoices
+	}
+	list = append(list, note.ID)
+	store.Set(ListInvoiceKey(), wire.BinaryBytes(list))
+
+	//track the remaining credit so it is applied to later payments
+	if note.Ctx.Open {
+		credits, err := getListBytes(store, ListCreditKey(profile.Name, receiver))
+		if err != nil {
+			return abciErrInternal(err)
+		}
+		credits = append(credits, note.ID)
+		store.Set(ListCreditKey(profile.Name, receiver), wire.BinaryBytes(credits))
+	}
+	return abci.OK
+}
+
+// applyCredits applies the unapplied credit of the open credit notes from the
+//   sender to the receiver to the invoices in order, returning the open credit
+//   notes so they may be stored alongside the invoices
+func applyCredits(store btypes.KVStore, sender, receiver string,
+	invoices []*types.Invoice, blockTime time.Time) (applied []types.Invoice, err error) {
+
+	list, err := getListBytes(store, ListCreditKey(sender, receiver))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range list {
+		note, err := getInvoice(store, id)
+		if err != nil {
+			return nil, err
+		}
+		ctx := note.GetCtx()
+		if !ctx.Open {
+			continue
+		}
+		creditNote := note.Unwrap().(*types.CreditNote)
+		for _, invoice := range invoices {
+			if invoice.GetCtx().AcceptedCur != ctx.AcceptedCur {
+				continue
+			}
+			err = creditNote.ApplyTo(invoice.GetID(), invoice.GetCtx(), blockTime)
+			if err != nil {
+				return nil, err
+			}
+		}
+		applied = append(applied, note)
+	}
+	return applied, nil
+}
+
+// storeCredits stores the credit notes returned by applyCredits, those
+//   which have been fully applied are removed from the open credit list
+func storeCredits(store btypes.KVStore, sender, receiver string, notes []types.Invoice) {
+	var open [][]byte
+	for _, note := range notes {
+		store.Set(InvoiceKey(note.GetID()), wire.BinaryBytes(note))
+		if note.GetCtx().Open {
+			open = append(open, note.GetID())
+		}
+	}
+	store.Set(ListCreditKey(sender, receiver), wire.BinaryBytes(open))
+}
@@ -0,0 +1,66 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestCreditNote(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("foo")
+	sender, receiver := f.sender, f.receiver
+
+	credit := func(addr []byte, amt string, ids ...[]byte) abci.Result {
+		tx := types.TxCreditNote{SenderAddr: addr, InvoiceIDs: ids, Amount: amt, Notes: "overbilled"}
+		return runTxCreditNote(f.store, addr, MarshalWithTB(tx, TBTxCreditNote))
+	}
+
+	first := f.openInvoice("100USD", "first")
+	res := f.pay("tx1", "40USD", first)
+	require.True(res.IsOK(), res.Log)
+
+	//only the invoice sender may credit it, by no more than was invoiced
+	assert.Equal(CodeTypeNotOwner, credit(receiver, "80USD", first).Code)
+	assert.True(credit(sender, "101USD", first).IsErr())
+	assert.True(credit(sender, "80USD").IsErr())
+
+	//the credit settles the unpaid balance, the remainder is kept
+	res = credit(sender, "80USD", first)
+	require.True(res.IsOK(), res.Log)
+	noteID := f.lastID()
+	assert.False(f.ctx(first).Open)
+	assert.Equal("60", f.ctx(first).Credited.Amount)
+	remaining, err := f.ctx(noteID).Unpaid()
+	require.Nil(err)
+	assert.Equal("20", remaining.Amount)
+	assert.True(f.ctx(noteID).Open)
+	credits := func() [][]byte {
+		ids, err := getListBytes(f.store, ListCreditKey("foo", "bar"))
+		require.Nil(err)
+		return ids
+	}
+	assert.Equal([][]byte{noteID}, credits())
+
+	//credit notes cannot be paid or voided
+	assert.True(f.pay("tx2", "20USD", noteID).IsErr())
+	tx := types.TxInvoiceVoid{ID: noteID, SenderAddr: sender, Reason: "mistake"}
+	assert.True(runTxInvoiceVoid(f.store, sender, MarshalWithTB(tx, TBTxExpenseVoid)).IsErr())
+
+	//the remaining credit is applied to the next payment between the profiles
+	second := f.openInvoice("50USD", "second")
+	res = f.pay("tx4", "30USD")
+	require.True(res.IsOK(), res.Log)
+	assert.False(f.ctx(second).Open)
+	assert.Equal("20", f.ctx(second).Credited.Amount)
+	assert.Equal("30", f.paid(second))
+	assert.False(f.ctx(noteID).Open)
+	assert.Empty(credits())
+}
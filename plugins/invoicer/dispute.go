@@ -0,0 +1,107 @@
+package invoicer
+
+import (
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func runTxInvoiceDispute(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	// Decode tx
+	var tx = new(types.TxInvoiceDispute)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
+	if err != nil {
+		return abciErrNoSender
+	}
+
+	//only the receiver of the invoice may dispute it
+	invoice, err := getInvoice(store, tx.ID)
+	if err != nil {
+		return abciErrInvoiceMissing
+	}
+	switch {
+	case isCreditNote(invoice):
+		return abciErrCreditNote
+	case invoice.GetCtx().Receiver != profile.Name:
+		return abciErrNotOwner("invoice was sent to another profile")
+	}
+
+	//large invoices of a multi-signature profile are disputed by the threshold of signers
+	if profile.RequiresSignatures(invoice.GetCtx().Invoiced) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	err = invoice.GetCtx().Dispute(tx.Reason, tx.Evidence, blockTime)
+	if err != nil {
+		return abci.ErrUnauthorized.AppendLog("Error disputing invoice: " + err.Error())
+	}
+	store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
+	return abci.OK
+}
+
+func runTxInvoiceRespond(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	// Decode tx
+	var tx = new(types.TxInvoiceRespond)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
+	if err != nil {
+		return abciErrNoSender
+	}
+
+	//only the sender of the invoice may respond to its dispute
+	invoice, err := getInvoice(store, tx.ID)
+	if err != nil {
+		return abciErrInvoiceMissing
+	}
+	switch {
+	case invoice.GetCtx().Sender != profile.Name:
+		return abciErrNotOwner("invoice was sent by another profile")
+	case len(tx.Response) == 0:
+		return abci.ErrInternalError.AppendLog("A response is required to resolve a dispute")
+	case tx.Resolution == types.DisputeAdjusted:
+		return abci.ErrInternalError.AppendLog("A disputed invoice is adjusted by editing it")
+	}
+
+	//disputes of large invoices are resolved by the threshold of signers
+	if profile.RequiresSignatures(invoice.GetCtx().Invoiced) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	err = invoice.GetCtx().Resolve(tx.Resolution, tx.Response)
+	if err != nil {
+		return abci.ErrUnauthorized.AppendLog("Error resolving dispute: " + err.Error())
+	}
+	store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
+	return abci.OK
+}
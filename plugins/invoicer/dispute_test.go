@@ -0,0 +1,73 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestInvoiceDispute(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("foo")
+	sender, receiver := f.sender, f.receiver
+
+	dispute := func(addr, id []byte, reason string) abci.Result {
+		tx := types.TxInvoiceDispute{ID: id, SenderAddr: addr, Reason: reason, Evidence: []byte{0x01, 0x02}}
+		return runTxInvoiceDispute(f.store, addr, MarshalWithTB(tx, TBTxInvoiceDispute))
+	}
+	respond := func(addr, id []byte, resolution, response string) abci.Result {
+		tx := types.TxInvoiceRespond{ID: id, SenderAddr: addr, Resolution: resolution, Response: response}
+		return runTxInvoiceRespond(f.store, addr, MarshalWithTB(tx, TBTxInvoiceRespond))
+	}
+	ctx := f.ctx
+
+	//only the receiver may dispute, with a reason
+	first := f.openInvoice("100USD", "first")
+	assert.Equal(CodeTypeNotOwner, dispute(sender, first, "too much").Code)
+	assert.True(dispute(receiver, first, "").IsErr())
+	res := dispute(receiver, first, "too much")
+	require.True(res.IsOK(), res.Log)
+	assert.True(ctx(first).Disputed())
+	assert.Equal([]byte{0x01, 0x02}, ctx(first).Disputes[0].Evidence)
+	assert.True(dispute(receiver, first, "again").IsErr())
+
+	//disputed invoices are not selected automatically
+	assert.True(f.pay("tx1", "100USD").IsErr())
+
+	//only the sender may respond, adjusting is done by editing
+	assert.Equal(CodeTypeNotOwner, respond(receiver, first, types.DisputeUpheld, "as agreed").Code)
+	assert.True(respond(sender, first, types.DisputeUpheld, "").IsErr())
+	assert.True(respond(sender, first, types.DisputeAdjusted, "fixed").IsErr())
+	res = respond(sender, first, types.DisputeUpheld, "as agreed")
+	require.True(res.IsOK(), res.Log)
+	assert.False(ctx(first).Disputed())
+	assert.Equal(types.DisputeUpheld, ctx(first).Disputes[0].Resolution)
+	assert.True(respond(sender, first, types.DisputeUpheld, "as agreed").IsErr())
+
+	//the dispute is resolved as adjusted when the sender edits the invoice
+	res = dispute(receiver, first, "still too much")
+	require.True(res.IsOK(), res.Log)
+	res = f.runInvoice(TBTxContractEdit, types.TxInvoice{EditID: first, Amount: "90USD", Notes: "discounted"})
+	require.True(res.IsOK(), res.Log)
+	disputes := ctx(first).Disputes
+	require.Equal(2, len(disputes))
+	assert.Equal(types.DisputeAdjusted, disputes[1].Resolution)
+	assert.Equal("discounted", disputes[1].Response)
+
+	//a withdrawn invoice is voided
+	second := f.openInvoice("100USD", "second")
+	res = dispute(receiver, second, "never ordered")
+	require.True(res.IsOK(), res.Log)
+	res = respond(sender, second, types.DisputeWithdrawn, "sent in error")
+	require.True(res.IsOK(), res.Log)
+	assert.True(ctx(second).Voided)
+	assert.Equal("sent in error", ctx(second).VoidReason)
+	assert.Equal(types.DisputeWithdrawn, ctx(second).Disputes[0].Resolution)
+}
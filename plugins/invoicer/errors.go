@@ -1,11 +1,19 @@
 package invoicer
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 
 	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
 )
 
+// CodeTypeNotOwner is returned when the signer of a tx does not own
+//   the profile, invoice or payment it acts upon
+const CodeTypeNotOwner abci.CodeType = 1001
+
 var (
 	errStateNotFound = errors.New("State not found")
 
@@ -13,6 +21,8 @@ var (
 	abciErrNoReceiver         = abci.ErrUnknownRequest.AppendLog("Receiver profile doesn't exist")
 	abciErrProfileNonExistent = abci.ErrUnknownRequest.AppendLog("Cannot modify a non-existent or deactivated profile")
 	abciErrProfileExists      = abci.ErrInternalError.AppendLog("Cannot create an already existing profile")
+	abciErrProfileReserved    = abci.ErrInternalError.AppendLog("Cannot create a profile with the name of a deactivated profile")
+	abciErrAddressTaken       = abci.ErrInternalError.AppendLog("Cannot use an address which controls another profile")
 	abciErrDupInvoice         = abci.ErrInternalError.AppendLog("Duplicate invoice, edit the invoice notes to make them unique")
 	abciErrNoProfile          = abci.ErrUnknownRequest.AppendLog("Error retrieving profile from store")
 	abciErrGetProfiles        = abci.ErrUnknownRequest.AppendLog("Error retrieving active profile list")
@@ -20,10 +30,22 @@ var (
 	abciErrGetInvoices        = abci.ErrUnknownRequest.AppendLog("Error retrieving active invoice list")
 	abciErrGetPayments        = abci.ErrUnknownRequest.AppendLog("Error retrieving payments list")
 	abciErrInvoiceMissing     = abci.ErrUnknownRequest.AppendLog("Error retrieving invoice to modify")
+	abciErrPaymentMissing     = abci.ErrUnknownRequest.AppendLog("Error retrieving payment to reverse")
+	abciErrDupPayment         = abci.ErrInternalError.AppendLog("Duplicate payment, the transaction ID has already been recorded")
+	abciErrPaymentReversed    = abci.ErrUnauthorized.AppendLog("Cannot reverse a payment which has already been reversed")
+	abciErrActionMissing      = abci.ErrUnknownRequest.AppendLog("Error retrieving action awaiting signatures")
 	abciErrBadTypeByte        = abci.ErrUnknownRequest.AppendLog("Unknown prepended type byte")
 	abciErrInvoiceClosed      = abci.ErrUnauthorized.AppendLog("Cannot edit closed invoice")
-	abciErrOverPayment        = abci.ErrUnauthorized.AppendLog("Error this is an overpayment")
+	abciErrInvoiceSettling    = abci.ErrUnauthorized.AppendLog("Cannot edit invoice with payments or credit applied")
+	abciErrInvoiceVoided      = abci.ErrUnauthorized.AppendLog("Cannot pay voided invoice")
+	abciErrInvoiceRejected    = abci.ErrUnauthorized.AppendLog("Cannot pay rejected invoice")
+	abciErrApprovalChain      = abci.ErrUnauthorized.AppendLog("Cannot pay invoice before its approval chain is complete")
+	abciErrCreditNote         = abci.ErrUnauthorized.AppendLog("Cannot pay, edit or void a credit note")
 	abciErrProfileInactive    = abci.ErrUnauthorized.AppendLog("Error profile is inactive")
+	abciErrNotOracle          = abci.ErrUnauthorized.AppendLog("Only a registered oracle may post exchange rates")
+	abciErrDupSchedule        = abci.ErrInternalError.AppendLog("Duplicate schedule, edit the invoice notes to make them unique")
+	abciErrScheduleMissing    = abci.ErrUnknownRequest.AppendLog("Error retrieving schedule to modify")
+	abciErrScheduleInactive   = abci.ErrUnauthorized.AppendLog("Cannot cancel an inactive schedule")
 )
 
 func wrapErrDecodingState(err error) error {
@@ -39,6 +61,27 @@ func abciErrDecimal(err error) abci.Result {
 	return abci.ErrBaseEncodingError.AppendLog("Error in decimal calculation: " + err.Error())
 }
 
+func abciErrNoRate(err error) abci.Result {
+	return abci.ErrUnknownRequest.AppendLog("Error converting currency: " + err.Error())
+}
+
+func abciErrBadAmount(err error) abci.Result {
+	return abci.ErrBaseEncodingError.AppendLog("Error malformed amount: " + err.Error())
+}
+
+func abciErrLineItemTotal(total, amt *types.AmtCurTime) abci.Result {
+	return abci.ErrInternalError.AppendLog(fmt.Sprintf("Line items total %v%v but the invoice amount is %v%v",
+		total.Amount, total.CurTime.Cur, amt.Amount, amt.CurTime.Cur))
+}
+
+func abciErrAllocation(err error) abci.Result {
+	return abci.ErrInternalError.AppendLog("Error allocating payment: " + err.Error())
+}
+
+func abciErrNotOwner(log string) abci.Result {
+	return abci.NewError(CodeTypeNotOwner, "Unauthorized: "+log)
+}
+
 func abciErrInternal(err error) abci.Result {
 	return abci.ErrInternalError.AppendLog("Error: " + err.Error())
 }
@@ -0,0 +1,137 @@
+package invoicer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+// fixture is a store at the block time of the tests with helpers to run its txs,
+//   the foo profile is controlled by the sender and the bar profile by the receiver
+type fixture struct {
+	require  *require.Assertions
+	store    btypes.KVStore
+	date     time.Time
+	sender   []byte
+	receiver []byte
+}
+
+// newFixture begins a block on a new store
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{
+		require:  require.New(t),
+		store:    btypes.NewMemKVStore(),
+		sender:   []byte("sender"),
+		receiver: []byte("receiver"),
+	}
+	f.beginBlock(time.Date(2017, time.Month(1), 31, 0, 0, 0, 0, time.UTC))
+	return f
+}
+
+// beginBlock begins a block at the date
+func (f *fixture) beginBlock(date time.Time) {
+	New().BeginBlock(f.store, nil, &abci.Header{Time: uint64(date.Unix())})
+	f.date = date
+}
+
+func (f *fixture) endBlock() {
+	New().EndBlock(f.store, 0)
+}
+
+// openProfile opens the profile controlled by the address
+func (f *fixture) openProfile(addr []byte, tx types.TxProfile) {
+	res := runTxProfile(f.store, addr, MarshalWithTB(tx, TBTxProfileOpen))
+	f.require.True(res.IsOK(), res.Log)
+}
+
+// openFooBar opens the USD profiles foo and bar, bar trusts the senders provided
+func (f *fixture) openFooBar(trusted ...string) {
+	f.openProfile(f.sender, types.TxProfile{Name: "foo", AcceptedCur: "USD", DueDurationDays: 14})
+	f.openProfile(f.receiver, types.TxProfile{Name: "bar", AcceptedCur: "USD", DueDurationDays: 14,
+		TrustedSenders: trusted})
+}
+
+func (f *fixture) amt(amt string) *types.AmtCurTime {
+	out, err := types.ParseAmtCurTime(amt, f.date)
+	f.require.Nil(err)
+	return out
+}
+
+// lastID returns the ID of the last invoice or credit note added
+func (f *fixture) lastID() []byte {
+	ids, err := getListBytes(f.store, ListInvoiceKey())
+	f.require.Nil(err)
+	f.require.NotEmpty(ids)
+	return ids[len(ids)-1]
+}
+
+func (f *fixture) ctx(id []byte) *types.Context {
+	invoice, err := getInvoice(f.store, id)
+	f.require.Nil(err)
+	return invoice.GetCtx()
+}
+
+// paid returns the amount paid on the invoice, "0" if nothing was paid
+func (f *fixture) paid(id []byte) string {
+	paid, err := f.ctx(id).Paid()
+	f.require.Nil(err)
+	if paid == nil {
+		return "0"
+	}
+	return paid.Amount
+}
+
+// runInvoice runs the invoice tx signed by its sender address,
+//   by default the invoice is sent from foo to bar
+func (f *fixture) runInvoice(tb byte, tx types.TxInvoice) abci.Result {
+	if tx.SenderAddr == nil {
+		tx.SenderAddr = f.sender
+	}
+	if len(tx.To) == 0 {
+		tx.To = "bar"
+	}
+	return runTxInvoice(f.store, tx.SenderAddr, MarshalWithTB(tx, tb))
+}
+
+// openInvoice opens a contract from foo to bar, returning its ID
+func (f *fixture) openInvoice(amt, notes string) []byte {
+	return f.openInvoiceTx(types.TxInvoice{Amount: amt, Notes: notes})
+}
+
+func (f *fixture) openInvoiceTx(tx types.TxInvoice) []byte {
+	res := f.runInvoice(TBTxContractOpen, tx)
+	f.require.True(res.IsOK(), res.Log)
+	return f.lastID()
+}
+
+// runPayment runs the payment tx signed by its sender address,
+//   by default the payment is sent from bar to foo
+func (f *fixture) runPayment(tx types.TxPayment) abci.Result {
+	if tx.SenderAddr == nil {
+		tx.SenderAddr = f.receiver
+	}
+	if len(tx.Receiver) == 0 {
+		tx.Receiver = "foo"
+	}
+	if len(tx.DateRange) == 0 {
+		tx.DateRange = ":"
+	}
+	return runTxPayment(f.store, tx.SenderAddr, MarshalWithTB(tx, TBTxPayment))
+}
+
+// pay pays the invoices from bar to foo, or those selected
+//   automatically if none are provided
+func (f *fixture) pay(txID, amt string, ids ...[]byte) abci.Result {
+	return f.runPayment(types.TxPayment{TransactionID: txID, IDs: ids, Amt: f.amt(amt)})
+}
+
+// sign approves the action awaiting signatures as the signer
+func (f *fixture) sign(addr, actionID []byte) abci.Result {
+	tx := types.TxActionSign{ID: actionID, SenderAddr: addr}
+	return runTxActionSign(f.store, addr, MarshalWithTB(tx, TBTxActionSign))
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/tendermint/trackomatron/types"
 )
 
-func validateInvoiceCtx(ctx *types.Context) abci.Result {
+func validateInvoiceCtx(ctx *types.Context, blockTime time.Time) abci.Result {
 	//Validate Tx
 	switch {
 	case len(ctx.Sender) == 0:
@@ -26,14 +26,66 @@ func validateInvoiceCtx(ctx *types.Context) abci.Result {
 		return abci.ErrInternalError.AppendLog("invoice must have an accepted currency")
 	case ctx.Payable == nil:
 		return abci.ErrInternalError.AppendLog("invoice amount is nil")
-	case ctx.Due.Before(time.Now()):
+	case ctx.Due.Before(blockTime):
 		return abci.ErrInternalError.AppendLog("cannot issue overdue invoice")
-	default:
-		return abci.OK
 	}
+
+	//amounts stored must be well formed for their currency
+	if _, err := types.GetCurrency(ctx.AcceptedCur); err != nil {
+		return abciErrBadAmount(err)
+	}
+	if err := ctx.Invoiced.Validate(); err != nil {
+		return abciErrBadAmount(err)
+	}
+	if err := ctx.Payable.Validate(); err != nil {
+		return abciErrBadAmount(err)
+	}
+	if ctx.Terms != nil {
+		if err := ctx.Terms.Validate(); err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	if ctx.LateFees != nil {
+		if err := ctx.LateFees.Validate(); err != nil {
+			return abciErrInternal(err)
+		}
+		if fee := ctx.LateFees.FlatFee; fee != nil && fee.CurTime.Cur != ctx.Payable.CurTime.Cur {
+			return abci.ErrInternalError.AppendLog("late fee must be in the payable currency")
+		}
+	}
+
+	//tax charged on top of the invoice must be included in the invoiced amount
+	net := ctx.Invoiced
+	if ctx.Tax != nil && !ctx.Tax.Inclusive {
+		net = ctx.Tax.Base
+		gross, err := net.Add(ctx.Tax.Total)
+		if err != nil {
+			return abciErrBadAmount(err)
+		}
+		if eq, err := gross.EQ(ctx.Invoiced); err != nil || !eq {
+			return abci.ErrInternalError.AppendLog("invoiced amount must include the tax")
+		}
+	}
+
+	//the invoiced amount must be the total of any line items, where the tax
+	//  is charged on top it includes the tax of the lines setting their own rate
+	if len(ctx.LineItems) > 0 {
+		sum := types.SumLineItems
+		if ctx.Tax != nil && !ctx.Tax.Inclusive {
+			sum = types.SumLineSubtotals
+		}
+		total, err := sum(ctx.LineItems)
+		if err != nil {
+			return abciErrBadAmount(err)
+		}
+		if eq, err := total.EQ(net); err != nil || !eq {
+			return abciErrLineItemTotal(total, net)
+		}
+	}
+	return abci.OK
 }
 
-func runTxInvoice(store btypes.KVStore, txBytes []byte) (res abci.Result) {
+func runTxInvoice(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
 
 	tb := txBytes[0]
 
@@ -44,13 +96,51 @@ func runTxInvoice(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 		return abciErrDecodingTX(err)
 	}
 
-	//get the sender's address
-	profile, err := getProfileFromAddress(store, tx.SenderAddr)
+	//get the sender's profile from the signer's address
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
 	if err != nil {
-		return abciErrInternal(err)
+		return abciErrNoSender
+	}
+
+	invoice, res := buildInvoice(store, tb, profile, tx)
+	if res.IsErr() {
+		return res
+	}
+
+	//large invoices from a multi-signature profile need the threshold of signers
+	if profile.RequiresSignatures(invoice.GetCtx().Invoiced) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
 	}
+
+	switch tb {
+	case TBTxContractOpen, TBTxExpenseOpen:
+		return runActionInvoice(store, invoice, false)
+	case TBTxContractEdit, TBTxExpenseEdit:
+		return runActionInvoice(store, invoice, true)
+	}
+	return abciErrBadTypeByte
+}
+
+// buildInvoice creates the invoice described by the tx sent from the profile,
+//   any fields not provided in the tx are populated from the profile defaults
+func buildInvoice(store btypes.KVStore, tb byte, profile *types.Profile,
+	tx *types.TxInvoice) (invoice types.Invoice, res abci.Result) {
+
 	sender := profile.Name
 
+	//all date defaults are relative to the current block
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return invoice, abciErrInternal(err)
+	}
+
 	var accCur string
 	if len(tx.Cur) > 0 {
 		accCur = tx.Cur
@@ -58,34 +148,92 @@ func runTxInvoice(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 		accCur = profile.AcceptedCur
 	}
 
-	date := time.Now()
+	date := blockTime
 	if len(tx.Date) > 0 {
 		date, err = time.Parse(common.TimeLayout, tx.Date)
 		if err != nil {
-			return abciErrInternal(err)
+			return invoice, abciErrInternal(err)
 		}
 	}
-	amt, err := types.ParseAmtCurTime(tx.Amount, date)
+
+	//the invoiced amount is derived from the line items if provided
+	lineItems, err := types.ParseLineItems(tx.LineItems, date)
 	if err != nil {
-		return abciErrInternal(err)
+		return invoice, abciErrBadAmount(err)
+	}
+	var amt *types.AmtCurTime
+	if len(lineItems) > 0 {
+		amt, err = types.SumLineItems(lineItems)
+		if err != nil {
+			return invoice, abciErrBadAmount(err)
+		}
+	}
+	if len(tx.Amount) > 0 {
+		txAmt, err := types.ParseAmtCurTime(tx.Amount, date)
+		if err != nil {
+			return invoice, abciErrInternal(err)
+		}
+		if amt == nil {
+			amt = txAmt
+		} else if eq, err := amt.EQ(txAmt); err != nil || !eq {
+			return invoice, abciErrLineItemTotal(amt, txAmt)
+		}
+	}
+	if amt == nil {
+		return invoice, abci.ErrInternalError.AppendLog("invoice must have an amount or line items")
+	}
+
+	//calculate the tax, adding it to the invoiced amount if charged on top
+	taxBreakdown, res := calculateInvoiceTax(store, tb, profile, tx.To, amt, lineItems)
+	if res.IsErr() {
+		return invoice, res
+	}
+	if taxBreakdown != nil && !taxBreakdown.Inclusive {
+		amt, err = taxBreakdown.Base.Add(taxBreakdown.Total)
+		if err != nil {
+			return invoice, abciErrDecimal(err)
+		}
 	}
 
 	//calculate payable amount based on invoiced and accepted cur
-	payable, err := common.ConvertAmtCurTime(accCur, amt)
+	payable, conversion, err := convertAmtCurTime(store, accCur, amt)
 	if err != nil {
-		return abciErrInternal(err)
+		return invoice, abciErrNoRate(err)
 	}
 
 	//retrieve flags, or if they aren't used, use the senders profile's default
 
+	terms := profile.Terms
+	if len(tx.Terms) > 0 {
+		terms, err = types.ParsePaymentTerms(tx.Terms)
+		if err != nil {
+			return invoice, abciErrInternal(err)
+		}
+	}
+
+	lateFees := profile.LateFees
+	if len(tx.LateFees) > 0 {
+		lateFees, err = types.ParseLateFeePolicy(tx.LateFees)
+		if err != nil {
+			return invoice, abciErrInternal(err)
+		}
+	}
+	lateFees, err = invoiceLateFees(store, lateFees, accCur, date)
+	if err != nil {
+		return invoice, abciErrNoRate(err)
+	}
+
 	var dueDate time.Time
-	if len(tx.DueDate) > 0 {
-		date, err = time.Parse(common.TimeLayout, tx.DueDate)
+	switch {
+	case len(tx.DueDate) > 0:
+		dueDate, err = time.Parse(common.TimeLayout, tx.DueDate)
 		if err != nil {
-			return abciErrInternal(err)
+			return invoice, abciErrInternal(err)
 		}
-	} else {
-		dueDate = time.Now().AddDate(0, 0, profile.DueDurationDays)
+	case terms != nil:
+		dueDate = date.AddDate(0, 0, terms.NetDays)
+	default:
+		dueDate = blockTime.AddDate(0, 0, profile.DueDurationDays)
 	}
 
 	var depositInfo string
@@ -95,8 +243,6 @@ func runTxInvoice(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 		depositInfo = profile.DepositInfo
 	}
 
-	var invoice types.Invoice
-
 	switch tb {
 	//if not an expense then we're almost done!
 	case TBTxContractOpen, TBTxContractEdit:
@@ -113,13 +259,23 @@ func runTxInvoice(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 		).Wrap()
 	case TBTxExpenseOpen, TBTxExpenseEdit:
 
-		taxes, err := types.ParseAmtCurTime(tx.TaxesPaid, date)
-		if err != nil {
-			return abciErrInternal(err)
+		//taxes paid default to those included in the amount for the jurisdiction
+		var taxes *types.AmtCurTime
+		switch {
+		case len(tx.TaxesPaid) > 0:
+			taxes, err = types.ParseAmtCurTime(tx.TaxesPaid, date)
+			if err != nil {
+				return invoice, abciErrInternal(err)
+			}
+		case taxBreakdown != nil:
+			taxes = taxBreakdown.Total
+		default:
+			return invoice, abci.ErrInternalError.AppendLog(
+				"expense must have the taxes paid or a sender with a tax jurisdiction")
 		}
 		docBytes, err := ioutil.ReadFile(tx.Receipt)
 		if err != nil {
-			return abciErrInternal(errors.Wrap(err, "Problem reading receipt file"))
+			return invoice, abciErrInternal(errors.Wrap(err, "Problem reading receipt file"))
 		}
 		_, filename := path.Split(tx.Receipt)
 
@@ -138,22 +294,78 @@ func runTxInvoice(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 			taxes,
 		).Wrap()
 	default:
-		return abciErrBadTypeByte
+		return invoice, abciErrBadTypeByte
 	}
 
-	switch tb {
-	case TBTxContractOpen, TBTxExpenseOpen:
-		return runActionInvoice(store, invoice, false)
-	case TBTxContractEdit, TBTxExpenseEdit:
-		return runActionInvoice(store, invoice, true)
+	//record how the payable amount was calculated
+	invoice.GetCtx().Conversion = conversion
+	invoice.GetCtx().LineItems = lineItems
+	invoice.GetCtx().Tax = taxBreakdown
+	invoice.GetCtx().Terms = terms
+	invoice.GetCtx().LateFees = lateFees
+	return invoice, abci.OK
+}
+
+func runTxInvoiceVoid(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	tb := txBytes[0]
+
+	// Decode tx
+	var tx = new(types.TxInvoiceVoid)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
 	}
-	return abciErrBadTypeByte
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
+	if err != nil {
+		return abciErrNoSender
+	}
+
+	invoice, err := getInvoice(store, tx.ID)
+	if err != nil {
+		return abciErrInvoiceMissing
+	}
+	_, isContract := invoice.Unwrap().(*types.Contract)
+	switch {
+	case isCreditNote(invoice):
+		return abciErrCreditNote
+	case tb == TBTxContractVoid && !isContract:
+		return abci.ErrInternalError.AppendLog("invoice is not a contract, void it as an expense")
+	case tb == TBTxExpenseVoid && isContract:
+		return abci.ErrInternalError.AppendLog("invoice is not an expense, void it as a contract")
+	case invoice.GetCtx().Sender != profile.Name:
+		return abciErrNotOwner("invoice was sent by another profile")
+	}
+
+	//voiding a large invoice of a multi-signature profile needs the threshold of signers
+	if profile.RequiresSignatures(invoice.GetCtx().Invoiced) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	err = invoice.GetCtx().Void(tx.Reason)
+	if err != nil {
+		return abci.ErrUnauthorized.AppendLog("Error voiding invoice: " + err.Error())
+	}
+	store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
+	return abci.OK
 }
 
 func runActionInvoice(store btypes.KVStore, invoice types.Invoice, shouldExist bool) (res abci.Result) {
 
 	//Validate
-	res = validateInvoiceCtx(invoice.GetCtx())
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	res = validateInvoiceCtx(invoice.GetCtx(), blockTime)
 	if res.IsErr() {
 		return res
 	}
@@ -178,6 +390,32 @@ func runActionInvoice(store btypes.KVStore, invoice types.Invoice, shouldExist b
 				if !storeInvoice.GetCtx().Open {
 					return abciErrInvoiceClosed
 				}
+				if isCreditNote(storeInvoice) {
+					return abciErrCreditNote
+				}
+				if storeInvoice.GetCtx().Sender != invoice.GetCtx().Sender {
+					return abciErrNotOwner("invoice was sent by another profile")
+				}
+				if len(storeInvoice.GetCtx().Allocations) > 0 || storeInvoice.GetCtx().Credited != nil {
+					return abciErrInvoiceSettling
+				}
+
+				//the settlement and audit history is kept through the edit, the edited
+				//  invoice must be approved again unless the receiver trusts the sender
+				prev, ctx := storeInvoice.GetCtx(), invoice.GetCtx()
+				ctx.Allocations, ctx.Credited, ctx.Discount = prev.Allocations, prev.Credited, prev.Discount
+				ctx.AccruedFee, ctx.AccruedInterest = prev.AccruedFee, prev.AccruedInterest
+				ctx.AccruedThrough = prev.AccruedThrough
+				ctx.ScheduleID = prev.ScheduleID
+
+				//editing a disputed invoice adjusts it, resolving the dispute
+				ctx.Disputes = prev.Disputes
+				if invoice.GetCtx().Disputed() {
+					err = invoice.GetCtx().Resolve(types.DisputeAdjusted, invoice.GetCtx().Notes)
+					if err != nil {
+						return abciErrInternal(err)
+					}
+				}
 
 				invoices = append(invoices[:i], invoices[i+1:]...)
 				found = true
@@ -196,13 +434,29 @@ func runActionInvoice(store btypes.KVStore, invoice types.Invoice, shouldExist b
 		invoice.SetID()
 	}
 
-	if _, err := getProfile(store, invoice.GetCtx().Sender); err != nil {
+	sender, err := getProfile(store, invoice.GetCtx().Sender)
+	if err != nil {
 		return abciErrNoSender
 	}
-	if _, err := getProfile(store, invoice.GetCtx().Receiver); err != nil {
+	receiver, err := getProfile(store, invoice.GetCtx().Receiver)
+	if err != nil {
 		return abciErrNoReceiver
 	}
 
+	//expenses sent to an organisation are submitted by its members
+	//  and pass through its approval chain
+	if _, isExpense := invoice.Unwrap().(*types.Expense); isExpense && receiver.IsOrg() {
+		if !receiver.HasRole(sender.Address, types.RoleSubmitter) {
+			return abciErrNotOwner("expenses to " + receiver.Name + " must be sent by a submitter")
+		}
+		invoice.GetCtx().Chain = receiver.ApprovalChain
+	}
+
+	//invoices from senders trusted by the receiver need no approval
+	if len(invoice.GetCtx().Chain) == 0 && receiver.Trusts(invoice.GetCtx().Sender) {
+		invoice.GetCtx().Approval = types.ApprovalApproved
+	}
+
 	//Return if the invoice already exists, aka no error was thrown
 	_, err = getInvoice(store, invoice.GetID())
 	if shouldExist && err != nil {
@@ -212,10 +466,24 @@ func runActionInvoice(store btypes.KVStore, invoice types.Invoice, shouldExist b
 		return abciErrDupInvoice
 	}
 
+	//credit held from overpayments is applied to new approved invoices
+	if !shouldExist && invoice.GetCtx().Approved() {
+		if err := applyBalance(store, invoice, blockTime); err != nil {
+			return abciErrDecimal(err)
+		}
+	}
+
 	//Store invoice
 	store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
 
 	invoices = append(invoices, invoice.GetID())
 	store.Set(ListInvoiceKey(), wire.BinaryBytes(invoices))
+
+	//track invoices which may accrue late charges
+	if invoice.GetCtx().LateFees != nil {
+		if err := addAccrual(store, invoice.GetID()); err != nil {
+			return abciErrInternal(err)
+		}
+	}
 	return abci.OK
 }
@@ -4,7 +4,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
 	wire "github.com/tendermint/go-wire"
 	"github.com/tendermint/trackomatron/common"
 	"github.com/tendermint/trackomatron/types"
@@ -23,7 +26,10 @@ func TestRunTxInvoice(t *testing.T) {
 	require.Nil(err)
 
 	//calculate payable amount based on invoiced and accepted cur
-	payable, err := common.ConvertAmtCurTime(accCur, amt)
+	rates := common.NewFixtureRateProvider([]types.ExchangeRate{
+		{From: "BTC", To: "USD", Date: date, Rate: "430.5"},
+	})
+	payable, _, err := common.ConvertAmtCurTime(rates, accCur, amt)
 	require.Nil(err)
 
 	var invoices [2]types.Invoice
@@ -63,3 +69,105 @@ func TestRunTxInvoice(t *testing.T) {
 		require.False(invoiceRead.Empty())
 	}
 }
+
+func TestBlockTime(t *testing.T) {
+	require := require.New(t)
+
+	store := btypes.NewMemKVStore()
+	blockTime, err := getBlockTime(store)
+	require.Nil(err)
+	require.True(blockTime.IsZero())
+
+	date := time.Date(2015, time.Month(12), 31, 0, 0, 0, 0, time.UTC)
+	New().BeginBlock(store, nil, &abci.Header{Time: uint64(date.Unix())})
+	blockTime, err = getBlockTime(store)
+	require.Nil(err)
+	require.True(date.Equal(blockTime))
+
+	//due dates are checked against the block time, not the wall clock
+	amt, err := types.ParseAmtCurTime("1000USD", date)
+	require.Nil(err)
+	ctx := &types.Context{
+		Sender:      "foo",
+		Receiver:    "bar",
+		AcceptedCur: "USD",
+		Invoiced:    amt,
+		Payable:     amt,
+		Due:         date.AddDate(0, 0, 1),
+	}
+	require.True(validateInvoiceCtx(ctx, blockTime).IsOK())
+	ctx.Due = date.AddDate(0, 0, -1)
+	require.True(validateInvoiceCtx(ctx, blockTime).IsErr())
+}
+
+func TestInvoiceVoid(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("foo")
+	sender, receiver := f.sender, f.receiver
+
+	void := func(addr, id []byte, tb byte, reason string) abci.Result {
+		tx := types.TxInvoiceVoid{ID: id, SenderAddr: addr, Reason: reason}
+		return runTxInvoiceVoid(f.store, addr, MarshalWithTB(tx, tb))
+	}
+
+	//only the sender may void, with a reason, as the right invoice type
+	id := f.openInvoice("100USD", "mistake")
+	assert.Equal(CodeTypeNotOwner, void(receiver, id, TBTxContractVoid, "wrong").Code)
+	assert.True(void(sender, id, TBTxContractVoid, "").IsErr())
+	assert.True(void(sender, id, TBTxExpenseVoid, "sent by mistake").IsErr())
+	res := void(sender, id, TBTxContractVoid, "sent by mistake")
+	require.True(res.IsOK(), res.Log)
+
+	assert.False(f.ctx(id).Open)
+	assert.True(f.ctx(id).Voided)
+	assert.Equal("sent by mistake", f.ctx(id).VoidReason)
+	assert.True(void(sender, id, TBTxContractVoid, "again").IsErr())
+
+	//voided invoices cannot be paid and are skipped by auto-selection
+	assert.True(f.pay("100USD", "100USD", id).IsErr())
+	assert.True(f.pay("100USD", "100USD").IsErr())
+	other := f.openInvoice("100USD", "other")
+	res = f.pay("50USD", "50USD")
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("50", f.paid(other))
+
+	//invoices with payments cannot be voided
+	assert.True(void(sender, other, TBTxContractVoid, "too late").IsErr())
+}
+
+func TestInvoiceEdit(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar()
+
+	edit := func(id []byte, amt, notes string) abci.Result {
+		return f.runInvoice(TBTxContractEdit, types.TxInvoice{EditID: id, Amount: amt, Notes: notes})
+	}
+
+	//an edit withdraws the approval of the receiver
+	id := f.openInvoice("100USD", "design")
+	approve := func(comment string) {
+		tx := types.TxInvoiceApproval{ID: id, SenderAddr: f.receiver, Comment: comment}
+		res := runTxInvoiceApproval(f.store, f.receiver, MarshalWithTB(tx, TBTxInvoiceApprove))
+		require.True(res.IsOK(), res.Log)
+	}
+	approve("looks good")
+	res := edit(id, "120USD", "design and build")
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("120", f.ctx(id).Invoiced.Amount)
+	assert.Equal(types.ApprovalPending, f.ctx(id).Approval)
+	assert.Empty(f.ctx(id).Comment)
+	approve("still fine")
+
+	//invoices which have been partly paid cannot be edited
+	res = f.pay("40USD", "40USD", id)
+	require.True(res.IsOK(), res.Log)
+	assert.True(edit(id, "90USD", "discounted").IsErr())
+	assert.Equal("40", f.paid(id))
+	assert.Equal("120", f.ctx(id).Invoiced.Amount)
+}
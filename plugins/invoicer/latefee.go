@@ -0,0 +1,86 @@
+package invoicer
+
+import (
+	"bytes"
+	"time"
+
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+// invoiceLateFees determines the late fee policy of a new invoice, the flat
+//   fee is converted to the payable currency at the invoice date
+func invoiceLateFees(store btypes.KVStore, policy *types.LateFeePolicy,
+	accCur string, date time.Time) (*types.LateFeePolicy, error) {
+
+	if policy == nil || policy.FlatFee == nil {
+		return policy, nil
+	}
+
+	//policies are parsed without a date, the fee is dated to the invoice
+	converted := *policy
+	fee := *policy.FlatFee
+	fee.CurTime.Date = date
+	converted.FlatFee = &fee
+	if fee.CurTime.Cur == accCur {
+		return &converted, nil
+	}
+	payable, _, err := convertAmtCurTime(store, accCur, &fee)
+	if err != nil {
+		return nil, err
+	}
+	converted.FlatFee = payable
+	return &converted, nil
+}
+
+// addAccrual adds an invoice to the list of invoices which accrue late charges
+func addAccrual(store btypes.KVStore, id []byte) error {
+	ids, err := getListBytes(store, ListAccrualKey())
+	if err != nil {
+		return err
+	}
+	for _, v := range ids {
+		if bytes.Compare(v, id) == 0 {
+			return nil
+		}
+	}
+	ids = append(ids, id)
+	store.Set(ListAccrualKey(), wire.BinaryBytes(ids))
+	return nil
+}
+
+// accrueLateFees accrues the late charges of all overdue invoices up to the
+//   block time, invoices which have closed are removed from the accrual list
+func accrueLateFees(store btypes.KVStore) error {
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return err
+	}
+	ids, err := getListBytes(store, ListAccrualKey())
+	if err != nil {
+		return err
+	}
+
+	var open [][]byte
+	for _, id := range ids {
+		invoice, err := getInvoice(store, id)
+		if err != nil || !invoice.GetCtx().Open {
+			continue
+		}
+		open = append(open, id)
+
+		changed, err := invoice.GetCtx().Accrue(blockTime)
+		if err != nil {
+			return err
+		}
+		if changed {
+			store.Set(InvoiceKey(id), wire.BinaryBytes(invoice))
+		}
+	}
+	if len(open) != len(ids) {
+		store.Set(ListAccrualKey(), wire.BinaryBytes(open))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package invoicer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestLateFeeAccrual(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("foo")
+
+	accruals := func() [][]byte {
+		ids, err := getListBytes(f.store, ListAccrualKey())
+		require.Nil(err)
+		return ids
+	}
+	unpaid := func(id []byte) (principal, total string) {
+		p, err := f.ctx(id).UnpaidPrincipal()
+		require.Nil(err)
+		u, err := f.ctx(id).Unpaid()
+		require.Nil(err)
+		return p.Amount, u.Amount
+	}
+
+	id := f.openInvoiceTx(types.TxInvoice{Amount: "1000USD", Notes: "late", DueDate: "2017-02-10",
+		LateFees: "fee 25USD, interest 1%/month"})
+	assert.Equal([][]byte{id}, accruals())
+
+	//nothing accrues before the invoice is due
+	f.beginBlock(time.Date(2017, time.Month(2), 5, 0, 0, 0, 0, time.UTC))
+	f.endBlock()
+	assert.Nil(f.ctx(id).AccruedFee)
+	assert.Nil(f.ctx(id).AccruedInterest)
+
+	//once overdue the fee is charged and interest accrues each whole month
+	f.beginBlock(time.Date(2017, time.Month(3), 15, 0, 0, 0, 0, time.UTC))
+	f.endBlock()
+	require.NotNil(f.ctx(id).AccruedFee)
+	require.NotNil(f.ctx(id).AccruedInterest)
+	assert.Equal("25", f.ctx(id).AccruedFee.Amount)
+	assert.Equal("10", f.ctx(id).AccruedInterest.Amount)
+	principal, total := unpaid(id)
+	assert.Equal("1000", principal)
+	assert.Equal("1035", total)
+
+	//payments settle the accrued charges before the principal
+	res := f.pay("tx1", "30USD", id)
+	require.True(res.IsOK(), res.Log)
+	principal, total = unpaid(id)
+	assert.Equal("1000", principal)
+	assert.Equal("1005", total)
+	res = f.pay("tx2", "1005USD", id)
+	require.True(res.IsOK(), res.Log)
+	assert.False(f.ctx(id).Open)
+
+	//closed invoices are removed from the accrual list
+	f.beginBlock(f.date.Add(time.Hour))
+	f.endBlock()
+	assert.Empty(accruals())
+}
+
+func TestLateFeeConversion(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("foo")
+	oracle := []byte("oracle")
+	require.Nil(addOracle(f.store, oracle))
+	rate := types.TxRate{From: "EUR", To: "USD", Rate: "1.1", Date: "2017-01-31"}
+	res := runTxRate(f.store, oracle, MarshalWithTB(rate, TBTxRate))
+	require.True(res.IsOK(), res.Log)
+
+	//the flat fee is converted at the date of the invoice
+	id := f.openInvoiceTx(types.TxInvoice{Amount: "100USD", Notes: "eur fee", LateFees: "fee 20EUR"})
+	fee := f.ctx(id).LateFees.FlatFee
+	assert.Equal("USD", fee.CurTime.Cur)
+	assert.Equal("22", fee.Amount)
+
+	//without a rate posted for the invoice date the invoice is refused
+	res = f.runInvoice(TBTxContractOpen, types.TxInvoice{Amount: "100USD", Notes: "no rate",
+		Date: "2017-01-30", LateFees: "fee 20EUR"})
+	assert.True(res.IsErr())
+}
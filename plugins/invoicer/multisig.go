@@ -0,0 +1,110 @@
+package invoicer
+
+import (
+	"bytes"
+
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+	"github.com/tendermint/tmlibs/merkle"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+// collectSignatures records the approval by the signer of an action of a
+//   multi-signature profile, identified by the hash of its tx. True is
+//   returned once the threshold of signers is reached and the action may run,
+//   otherwise the result holds the ID of the action awaiting signatures.
+func collectSignatures(store btypes.KVStore, profile *types.Profile,
+	signer []byte, txBytes []byte) (bool, abci.Result) {
+
+	id := merkle.SimpleHashFromBinary(txBytes)
+	action, err := getPendingAction(store, id)
+	switch {
+	case err == errStateNotFound || (err == nil && action.Executed):
+		blockTime, err := getBlockTime(store)
+		if err != nil {
+			return false, abciErrInternal(err)
+		}
+		action = types.PendingAction{
+			ID:       id,
+			Profile:  profile.Name,
+			Proposer: signer,
+			TxBytes:  txBytes,
+			Proposed: blockTime,
+		}
+		actions, err := getListBytes(store, ListPendingActionKey())
+		if err != nil {
+			return false, abciErrInternal(err)
+		}
+		actions = append(actions, id)
+		store.Set(ListPendingActionKey(), wire.BinaryBytes(actions))
+	case err != nil:
+		return false, abciErrInternal(err)
+	}
+
+	//the proposer approves the action if it is one of the signers
+	if profile.Controls(signer) && !action.HasApproved(signer) {
+		action.Approvals = append(action.Approvals, signer)
+	}
+
+	if action.Signatures(profile) < profile.Threshold {
+		store.Set(PendingActionKey(id), wire.BinaryBytes(action))
+		return false, abci.NewResultOK(id, "action awaiting signatures")
+	}
+
+	//the action runs, remove it from the actions awaiting signatures
+	action.Executed = true
+	store.Set(PendingActionKey(id), wire.BinaryBytes(action))
+	actions, err := getListBytes(store, ListPendingActionKey())
+	if err != nil {
+		return false, abciErrInternal(err)
+	}
+	for i, v := range actions {
+		if bytes.Equal(v, id) {
+			actions = append(actions[:i], actions[i+1:]...)
+			break
+		}
+	}
+	store.Set(ListPendingActionKey(), wire.BinaryBytes(actions))
+	return true, abci.OK
+}
+
+func runTxActionSign(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	// Decode tx
+	var tx = new(types.TxActionSign)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+
+	//only the signers of the profile may approve its actions
+	action, err := getPendingAction(store, tx.ID)
+	if err != nil || action.Executed {
+		return abciErrActionMissing
+	}
+	profile, err := getProfile(store, action.Profile)
+	if err != nil {
+		return abciErrNoProfile
+	}
+	switch {
+	case !profile.Controls(callerAddr):
+		return abciErrNotOwner("only the signers of " + profile.Name + " may approve its actions")
+	case action.HasApproved(callerAddr):
+		return abci.ErrInternalError.AppendLog("The action has already been approved by this signer")
+	}
+	action.Approvals = append(action.Approvals, callerAddr)
+	store.Set(PendingActionKey(action.ID), wire.BinaryBytes(action))
+
+	//run the action as its proposer once the threshold is reached
+	if action.Signatures(&profile) < profile.Threshold {
+		return abci.NewResultOK(action.ID, "action awaiting signatures")
+	}
+	return runTx(store, action.Proposer, action.TxBytes)
+}
@@ -0,0 +1,216 @@
+package invoicer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestMultisigProfile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+
+	first, second, third, vendor := []byte("first"), []byte("second"), []byte("third"), []byte("vendor")
+	signers := [][]byte{second, third}
+
+	//the threshold cannot exceed the signers, which include the profile address
+	res := runTxProfile(f.store, first, MarshalWithTB(types.TxProfile{Name: "acme", AcceptedCur: "USD",
+		DueDurationDays: 14, Signers: signers, Threshold: 4}, TBTxProfileOpen))
+	assert.True(res.IsErr())
+	res = runTxProfile(f.store, first, MarshalWithTB(types.TxProfile{Name: "acme", AcceptedCur: "USD",
+		DueDurationDays: 14, TrustedSenders: []string{"vend"}, Signers: signers, Threshold: 2,
+		Limit: "1000USD"}, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+	f.openProfile(vendor, types.TxProfile{Name: "vend", AcceptedCur: "USD", DueDurationDays: 14})
+	acme, err := getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.Equal(3, len(acme.Signers))
+
+	id := f.openInvoiceTx(types.TxInvoice{Amount: "2500USD", SenderAddr: vendor, To: "acme", Notes: "servers"})
+
+	pay := func(addr []byte, txID, amt string) abci.Result {
+		return f.runPayment(types.TxPayment{TransactionID: txID, SenderAddr: addr, IDs: [][]byte{id},
+			Receiver: "vend", Amt: f.amt(amt)})
+	}
+	sign := f.sign
+	paid := func() string {
+		return f.paid(id)
+	}
+	pending := func() int {
+		actions, err := getListBytes(f.store, ListPendingActionKey())
+		require.Nil(err)
+		return len(actions)
+	}
+
+	//payments within the limit need a single signer
+	res = pay(second, "tx1", "500USD")
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("500", paid())
+
+	//larger payments wait for the threshold of signers
+	res = pay(second, "tx2", "2000USD")
+	require.True(res.IsOK(), res.Log)
+	actionID := res.Data
+	assert.Equal("500", paid())
+	assert.Equal(1, pending())
+	action, err := getPendingAction(f.store, actionID)
+	require.Nil(err)
+	assert.Equal("acme", action.Profile)
+
+	assert.Equal(CodeTypeNotOwner, sign(vendor, actionID).Code)
+	assert.True(sign(second, actionID).IsErr())
+	res = sign(third, actionID)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("2500", paid())
+	assert.Equal(0, pending())
+	assert.Equal(abciErrActionMissing, sign(first, actionID))
+
+	//profile edits always wait for the threshold of signers
+	edit := types.TxProfile{Name: "acme", AcceptedCur: "USD", DueDurationDays: 30,
+		Signers: signers, Threshold: 2}
+	res = runTxProfile(f.store, third, MarshalWithTB(edit, TBTxProfileEdit))
+	require.True(res.IsOK(), res.Log)
+	acme, err = getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.Equal(14, acme.DueDurationDays)
+	res = sign(first, res.Data)
+	require.True(res.IsOK(), res.Log)
+	acme, err = getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.Equal(30, acme.DueDurationDays)
+	assert.Equal(first, acme.Address)
+	assert.Nil(acme.Limit)
+}
+
+func TestMultisigActions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+
+	first, second, third, vendor := []byte("first"), []byte("second"), []byte("third"), []byte("vendor")
+	res := runTxProfile(f.store, first, MarshalWithTB(types.TxProfile{Name: "acme", AcceptedCur: "USD",
+		DueDurationDays: 14, Signers: [][]byte{second, third}, Threshold: 2, Limit: "1000USD"}, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+	f.openProfile(vendor, types.TxProfile{Name: "vend", AcceptedCur: "USD", DueDurationDays: 14,
+		TrustedSenders: []string{"acme"}})
+
+	//propose runs the tx as the second signer, it must wait for the third
+	propose := func(res abci.Result) []byte {
+		require.True(res.IsOK(), res.Log)
+		_, err := getPendingAction(f.store, res.Data)
+		require.Nil(err, res.Log)
+		return res.Data
+	}
+	sign := func(actionID []byte) {
+		res := f.sign(third, actionID)
+		require.True(res.IsOK(), res.Log)
+	}
+	openInvoice := func(addr []byte, to, amt, notes string) []byte {
+		res := f.runInvoice(TBTxContractOpen, types.TxInvoice{Amount: amt, SenderAddr: addr, To: to, Notes: notes})
+		if bytes.Equal(addr, second) {
+			sign(propose(res))
+		} else {
+			require.True(res.IsOK(), res.Log)
+		}
+		return f.lastID()
+	}
+
+	//credit notes
+	id := openInvoice(second, "vend", "3000USD", "credited")
+	credit := types.TxCreditNote{SenderAddr: second, InvoiceIDs: [][]byte{id}, Amount: "1500USD", Notes: "overbilled"}
+	actionID := propose(runTxCreditNote(f.store, second, MarshalWithTB(credit, TBTxCreditNote)))
+	assert.Nil(f.ctx(id).Credited)
+	sign(actionID)
+	assert.Equal("1500", f.ctx(id).Credited.Amount)
+
+	//voiding invoices
+	id = openInvoice(second, "vend", "2000USD", "voided")
+	void := types.TxInvoiceVoid{ID: id, SenderAddr: second, Reason: "mistake"}
+	actionID = propose(runTxInvoiceVoid(f.store, second, MarshalWithTB(void, TBTxContractVoid)))
+	assert.False(f.ctx(id).Voided)
+	sign(actionID)
+	assert.True(f.ctx(id).Voided)
+
+	//responding to disputes
+	id = openInvoice(second, "vend", "2000USD", "disputed by vend")
+	dispute := types.TxInvoiceDispute{ID: id, SenderAddr: vendor, Reason: "not delivered"}
+	res = runTxInvoiceDispute(f.store, vendor, MarshalWithTB(dispute, TBTxInvoiceDispute))
+	require.True(res.IsOK(), res.Log)
+	respond := types.TxInvoiceRespond{ID: id, SenderAddr: second, Resolution: types.DisputeUpheld,
+		Response: "delivered on time"}
+	actionID = propose(runTxInvoiceRespond(f.store, second, MarshalWithTB(respond, TBTxInvoiceRespond)))
+	assert.True(f.ctx(id).Disputed())
+	sign(actionID)
+	assert.False(f.ctx(id).Disputed())
+
+	//reversing payments
+	res = f.runPayment(types.TxPayment{TransactionID: "tx1", SenderAddr: vendor, IDs: [][]byte{id},
+		Receiver: "acme", Amt: f.amt("2000USD")})
+	require.True(res.IsOK(), res.Log)
+	reverse := types.TxPaymentReverse{TransactionID: "tx1", SenderAddr: second, RefundID: "rf1", Reason: "returned"}
+	actionID = propose(runTxPaymentReverse(f.store, second, MarshalWithTB(reverse, TBTxRefund)))
+	assert.Equal("2000", f.paid(id))
+	sign(actionID)
+	assert.Equal("0", f.paid(id))
+
+	//opening schedules
+	schedule := types.TxScheduleOpen{
+		Invoice: types.TxInvoice{Amount: "100USD", SenderAddr: second, To: "vend"},
+		Cadence: "monthly",
+	}
+	actionID = propose(runTxScheduleOpen(f.store, second, MarshalWithTB(schedule, TBTxScheduleOpen)))
+	schedules := func() int {
+		ids, err := getListBytes(f.store, ListScheduleKey())
+		require.Nil(err)
+		return len(ids)
+	}
+	assert.Equal(0, schedules())
+	sign(actionID)
+	assert.Equal(1, schedules())
+
+	//approving invoices
+	id = openInvoice(vendor, "acme", "2000USD", "approved")
+	approve := types.TxInvoiceApproval{ID: id, SenderAddr: second}
+	actionID = propose(runTxInvoiceApproval(f.store, second, MarshalWithTB(approve, TBTxInvoiceApprove)))
+	assert.False(f.ctx(id).Approved())
+	sign(actionID)
+	assert.True(f.ctx(id).Approved())
+
+	//disputing invoices
+	dispute = types.TxInvoiceDispute{ID: id, SenderAddr: second, Reason: "not delivered"}
+	actionID = propose(runTxInvoiceDispute(f.store, second, MarshalWithTB(dispute, TBTxInvoiceDispute)))
+	assert.False(f.ctx(id).Disputed())
+	sign(actionID)
+	assert.True(f.ctx(id).Disputed())
+
+	//managing the organisation
+	member := types.TxOrgMember{Org: "acme", SenderAddr: second, Address: vendor,
+		Roles: []string{types.RoleSubmitter}}
+	actionID = propose(runTxOrgMember(f.store, second, MarshalWithTB(member, TBTxOrgMemberSet)))
+	acme, err := getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.False(acme.IsOrg())
+	sign(actionID)
+	acme, err = getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.True(acme.HasRole(vendor, types.RoleSubmitter))
+
+	steps := []types.ApprovalStep{{Name: "finance", Approvers: [][]byte{third}}}
+	chain := types.TxOrgChain{Org: "acme", SenderAddr: second, Steps: steps}
+	actionID = propose(runTxOrgChain(f.store, second, MarshalWithTB(chain, TBTxOrgChain)))
+	acme, err = getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.Empty(acme.ApprovalChain)
+	sign(actionID)
+	acme, err = getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.Len(acme.ApprovalChain, 1)
+}
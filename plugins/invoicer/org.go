@@ -0,0 +1,103 @@
+package invoicer
+
+import (
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+// getOrgAsAdmin retrieves the active organisation profile to be managed,
+//   the signer must hold the admin role
+func getOrgAsAdmin(store btypes.KVStore, name string, callerAddr []byte) (*types.Profile, abci.Result) {
+	org, err := getProfile(store, name)
+	if err != nil {
+		return nil, abciErrNoProfile
+	}
+	if !org.Active {
+		return nil, abciErrProfileInactive
+	}
+	if !org.HasRole(callerAddr, types.RoleAdmin) {
+		return nil, abciErrNotOwner("only an admin may manage " + name)
+	}
+	return &org, abci.OK
+}
+
+func runTxOrgMember(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	tb := txBytes[0]
+
+	// Decode tx
+	var tx = new(types.TxOrgMember)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	org, res := getOrgAsAdmin(store, tx.Org, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+
+	//changes to a multi-signature organisation wait for the threshold of signers
+	if org.RequiresSignatures(nil) {
+		ready, res := collectSignatures(store, org, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	switch tb {
+	case TBTxOrgMemberSet:
+		err = org.SetMember(tx.Address, tx.Roles)
+	case TBTxOrgMemberRemove:
+		err = org.RemoveMember(tx.Address)
+	default:
+		return abciErrBadTypeByte
+	}
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	store.Set(ProfileKey(org.Name), wire.BinaryBytes(*org))
+	return abci.OK
+}
+
+func runTxOrgChain(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	// Decode tx
+	var tx = new(types.TxOrgChain)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	org, res := getOrgAsAdmin(store, tx.Org, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+
+	//an empty chain removes the approval chain
+	if err := org.ValidateApprovalChain(tx.Steps); err != nil {
+		return abciErrInternal(err)
+	}
+
+	//changes to a multi-signature organisation wait for the threshold of signers
+	if org.RequiresSignatures(nil) {
+		ready, res := collectSignatures(store, org, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+	org.ApprovalChain = tx.Steps
+	store.Set(ProfileKey(org.Name), wire.BinaryBytes(*org))
+	return abci.OK
+}
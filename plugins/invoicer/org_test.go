@@ -0,0 +1,120 @@
+package invoicer
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestOrgApprovalChain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+
+	receipt, err := ioutil.TempFile("", "receipt")
+	require.Nil(err)
+	defer os.Remove(receipt.Name())
+
+	owner, employee, vendor := []byte("owner"), []byte("employee"), []byte("vendor")
+	manager, finance, payer := []byte("manager"), []byte("finance"), []byte("payer")
+	for addr, name := range map[string]string{"owner": "acme", "employee": "emp", "vendor": "vend"} {
+		f.openProfile([]byte(addr), types.TxProfile{Name: name, AcceptedCur: "USD", DueDurationDays: 14})
+	}
+
+	member := func(addr []byte, tb byte, address []byte, roles ...string) abci.Result {
+		tx := types.TxOrgMember{Org: "acme", SenderAddr: addr, Address: address, Roles: roles}
+		return runTxOrgMember(f.store, addr, MarshalWithTB(tx, tb))
+	}
+	chain := func(addr []byte, steps ...types.ApprovalStep) abci.Result {
+		tx := types.TxOrgChain{Org: "acme", SenderAddr: addr, Steps: steps}
+		return runTxOrgChain(f.store, addr, MarshalWithTB(tx, TBTxOrgChain))
+	}
+	openExpense := func(addr []byte, notes string) abci.Result {
+		return f.runInvoice(TBTxExpenseOpen, types.TxInvoice{Amount: "100USD", SenderAddr: addr, To: "acme",
+			Notes: notes, Receipt: receipt.Name(), TaxesPaid: "0USD"})
+	}
+	approve := func(addr, id []byte) abci.Result {
+		tx := types.TxInvoiceApproval{ID: id, SenderAddr: addr}
+		return runTxInvoiceApproval(f.store, addr, MarshalWithTB(tx, TBTxInvoiceApprove))
+	}
+	pay := func(addr []byte, txID string, id []byte) abci.Result {
+		return f.runPayment(types.TxPayment{TransactionID: txID, SenderAddr: addr, IDs: [][]byte{id},
+			Receiver: "emp", Amt: f.amt("100USD"), Org: "acme"})
+	}
+	ctx := f.ctx
+
+	//only admins manage the members, with known roles
+	assert.Equal(CodeTypeNotOwner, member(manager, TBTxOrgMemberSet, manager, types.RoleAdmin).Code)
+	assert.True(member(owner, TBTxOrgMemberSet, manager, "boss").IsErr())
+	assert.True(member(owner, TBTxOrgMemberSet, owner, types.RoleApprover).IsErr())
+	for _, m := range []struct {
+		addr  []byte
+		roles []string
+	}{
+		{employee, []string{types.RoleSubmitter}},
+		{manager, []string{types.RoleApprover}},
+		{finance, []string{types.RoleApprover, types.RoleAdmin}},
+		{payer, []string{types.RolePayer}},
+	} {
+		res := member(owner, TBTxOrgMemberSet, m.addr, m.roles...)
+		require.True(res.IsOK(), res.Log)
+	}
+
+	//the approval chain is made of approvers, and may be set by any admin
+	managerStep := types.ApprovalStep{Name: "manager", Approvers: [][]byte{manager}}
+	financeStep := types.ApprovalStep{Name: "finance", Approvers: [][]byte{finance}}
+	assert.True(chain(finance, types.ApprovalStep{Name: "pay", Approvers: [][]byte{payer}}).IsErr())
+	assert.True(chain(finance, managerStep, managerStep).IsErr())
+	res := chain(finance, managerStep, financeStep)
+	require.True(res.IsOK(), res.Log)
+
+	//members and the chain survive profile edits
+	res = runTxProfile(f.store, owner, MarshalWithTB(
+		types.TxProfile{Name: "acme", AcceptedCur: "USD", DueDurationDays: 30}, TBTxProfileEdit))
+	require.True(res.IsOK(), res.Log)
+	org, err := getProfile(f.store, "acme")
+	require.Nil(err)
+	assert.Equal(4, len(org.Members))
+	assert.Equal(2, len(org.ApprovalChain))
+
+	//expenses are sent to the organisation by submitters
+	assert.Equal(CodeTypeNotOwner, openExpense(vendor, "lunch").Code)
+	res = openExpense(employee, "flights")
+	require.True(res.IsOK(), res.Log)
+	id := f.lastID()
+	assert.Equal(2, len(ctx(id).Chain))
+
+	//the expense is approved by each step of the chain in order before it is paid
+	assert.Equal(abciErrApprovalChain, pay(payer, "tx1", id))
+	assert.True(approve(finance, id).IsErr())
+	res = approve(manager, id)
+	require.True(res.IsOK(), res.Log)
+	assert.Equal(types.ApprovalPending, ctx(id).Approval)
+	assert.Equal(abciErrApprovalChain, pay(payer, "tx1", id))
+	res = approve(finance, id)
+	require.True(res.IsOK(), res.Log)
+	assert.True(ctx(id).Approved())
+	assert.Equal(2, len(ctx(id).ChainApprovals))
+
+	//payers pay on behalf of the organisation
+	assert.Equal(CodeTypeNotOwner, pay(manager, "tx1", id).Code)
+	res = pay(payer, "tx1", id)
+	require.True(res.IsOK(), res.Log)
+	assert.False(ctx(id).Open)
+	payment, err := getPayment(f.store, []byte("tx1"))
+	require.Nil(err)
+	assert.Equal("acme", payment.Sender)
+
+	//removed members lose their roles
+	res = member(owner, TBTxOrgMemberRemove, employee)
+	require.True(res.IsOK(), res.Log)
+	assert.True(member(owner, TBTxOrgMemberRemove, employee).IsErr())
+	assert.Equal(CodeTypeNotOwner, openExpense(employee, "hotel").Code)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/pkg/errors"
+
 	abci "github.com/tendermint/abci/types"
 	btypes "github.com/tendermint/basecoin/types"
 	"github.com/tendermint/go-wire"
@@ -12,7 +14,7 @@ import (
 	types "github.com/tendermint/trackomatron/types"
 )
 
-func validatePayment(ctx types.Context) abci.Result {
+func validatePayment(ctx types.Context, blockTime time.Time) abci.Result {
 	//Validate Tx
 	switch {
 	case len(ctx.Sender) == 0:
@@ -23,14 +25,14 @@ func validatePayment(ctx types.Context) abci.Result {
 		return abci.ErrInternalError.AppendLog("Invoice must have an accepted currency")
 	case ctx.Payable == nil:
 		return abci.ErrInternalError.AppendLog("Invoice amount is nil")
-	case ctx.Due.Before(time.Now()):
+	case ctx.Due.Before(blockTime):
 		return abci.ErrInternalError.AppendLog("Cannot issue overdue invoice")
 	default:
 		return abci.OK
 	}
 }
 
-func runTxPayment(store btypes.KVStore, txBytes []byte) (res abci.Result) {
+func runTxPayment(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
 
 	// Decode tx
 	var tx = new(types.TxPayment)
@@ -39,17 +41,47 @@ func runTxPayment(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 		return abciErrDecodingTX(err)
 	}
 
-	//get the sender's address
-	profile, err := getProfileFromAddress(store, tx.SenderAddr)
-	if err != nil {
-		abciErrInternal(err)
+	//get the sender's profile from the signer's address, or the
+	//  organisation the signer pays on behalf of
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	var profile *types.Profile
+	if len(tx.Org) > 0 {
+		org, err := getProfile(store, tx.Org)
+		if err != nil {
+			return abciErrNoSender
+		}
+		if !org.HasRole(callerAddr, types.RolePayer) {
+			return abciErrNotOwner("only a payer may pay on behalf of " + tx.Org)
+		}
+		profile = &org
+	} else {
+		profile, err = getProfileFromAddress(store, callerAddr)
+		if err != nil {
+			return abciErrNoSender
+		}
 	}
 	sender := profile.Name
 
+	//large payments from a multi-signature profile need the threshold of signers
+	if profile.RequiresSignatures(tx.Amt) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
 	//parse the date range
 	startDate, endDate, err := trcmn.ParseDateRange(tx.DateRange)
 	if err != nil {
-		abciErrInternal(err)
+		return abciErrInternal(err)
+	}
+
+	strategy, err := types.ParseAllocationStrategy(tx.Allocation)
+	if err != nil {
+		return abciErrAllocation(err)
 	}
 
 	payment := types.NewPayment(
@@ -58,10 +90,28 @@ func runTxPayment(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 		sender,
 		tx.Receiver,
 		tx.Amt,
+		strategy,
 		startDate,
 		endDate,
 	)
 
+	//explicit amounts are given for each of the IDs provided
+	if strategy == types.AllocateExplicit {
+		if len(payment.InvoiceIDs) == 0 {
+			return abciErrAllocation(errors.New("explicit allocation requires the invoice IDs"))
+		}
+		if err := validateExplicitAmounts(payment, tx.Amounts); err != nil {
+			return abciErrAllocation(err)
+		}
+	}
+
+	//each invoice may only be paid once by a payment
+	for i, id := range payment.InvoiceIDs {
+		if types.BytesIn(payment.InvoiceIDs[:i], id) {
+			return abciErrAllocation(fmt.Errorf("invoice ID %x is provided more than once", id))
+		}
+	}
+
 	//If there are no IDs provided in payment tx
 	// then populate them based on date
 	if len(payment.InvoiceIDs) == 0 {
@@ -82,6 +132,17 @@ func runTxPayment(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 			}
 			ctx := invoice.GetCtx()
 
+			//skip invoices which are not owed by the sender to the receiver
+			if ctx.Sender != payment.Receiver || ctx.Receiver != payment.Sender {
+				continue
+			}
+
+			//skip closed or voided invoices and credit notes, they are not owed,
+			//  invoices which the payer has not approved or has disputed
+			if !ctx.Open || ctx.Voided || isCreditNote(invoice) || !ctx.Approved() || ctx.Disputed() {
+				continue
+			}
+
 			//skip record if out of the date range
 			d := ctx.Invoiced.CurTime.Date
 			if (!payment.StartDate.IsZero() && d.Before(payment.StartDate)) ||
@@ -94,12 +155,18 @@ func runTxPayment(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 	}
 
 	//Validate Tx
+	if err := payment.PaymentCurTime.Validate(); err != nil {
+		return abciErrBadAmount(err)
+	}
 	switch {
 	case len(payment.InvoiceIDs) == 0:
 		return abci.ErrInternalError.AppendLog("Payment doesn't contain any IDs to close!")
 	case len(payment.TransactionID) == 0:
 		return abci.ErrInternalError.AppendLog("Payment must include a transaction ID")
 	}
+	if _, err := getPayment(store, []byte(payment.TransactionID)); err == nil {
+		return abciErrDupPayment
+	}
 
 	//Get all invoices, verify the ID
 	var invoices []*types.Invoice
@@ -108,20 +175,63 @@ func runTxPayment(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 		if err != nil {
 			return abciErrInvoiceMissing
 		}
-		invoices = append([]*types.Invoice{&invoice}, invoices...)
+		if invoice.GetCtx().Voided {
+			return abciErrInvoiceVoided
+		}
+		if isCreditNote(invoice) {
+			return abciErrCreditNote
+		}
+		if invoice.GetCtx().Approval == types.ApprovalRejected {
+			return abciErrInvoiceRejected
+		}
+		if invoice.GetCtx().NextStep() != nil {
+			return abciErrApprovalChain
+		}
+		invoices = append(invoices, &invoice)
 		if invoice.GetCtx().Sender != payment.Receiver {
 			return abci.ErrInternalError.AppendLog(
-				fmt.Sprintf("Invoice ID %x has receiver %v but the payment is to receiver %v!",
+				fmt.Sprintf("Invoice ID %x has sender %v but the payment is to receiver %v!",
 					invoice.GetID(),
-					invoice.GetCtx().Receiver,
+					invoice.GetCtx().Sender,
 					payment.Receiver))
 		}
+		if invoice.GetCtx().Receiver != payment.Sender {
+			return abciErrNotOwner(fmt.Sprintf("invoice ID %x is not owed by %v",
+				invoice.GetID(), payment.Sender))
+		}
+	}
+
+	//early payment discounts are available based on when the payment is recorded
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+
+	//unapplied credit from the receiver is applied before the payment
+	creditNotes, err := applyCredits(store, payment.Receiver, payment.Sender, invoices, blockTime)
+	if err != nil {
+		return abciErrDecimal(err)
+	}
+
+	//payments in another currency are converted at the rates posted for the payment date
+	payCur, payDate := payment.PaymentCurTime.CurTime.Cur, payment.PaymentCurTime.CurTime.Date
+	rates := make(map[string]string)
+	for _, invoice := range invoices {
+		cur := invoice.GetCtx().Payable.CurTime.Cur
+		if _, ok := rates[cur]; ok {
+			continue
+		}
+		rates[cur], err = paymentRate(store, payCur, cur, payDate)
+		if err != nil {
+			return abciErrNoRate(err)
+		}
 	}
 
-	//Make sure that the invoice is not paying too much!
+	//Total the amount required to settle the invoices in the payment currency
 	var totalCost *types.AmtCurTime
 	for _, invoice := range invoices {
-		unpaid, err := invoice.GetCtx().Unpaid()
+		ctx := invoice.GetCtx()
+		unpaid, err := ctx.UnpaidAt(payCur, rates[ctx.Payable.CurTime.Cur], blockTime)
 		if err != nil {
 			return abciErrDecimal(err)
 		}
@@ -130,24 +240,37 @@ func runTxPayment(store btypes.KVStore, txBytes []byte) (res abci.Result) {
 			return abciErrDecimal(err)
 		}
 	}
+	//any excess over the total unpaid is kept as a credit balance
+	fund := payment.PaymentCurTime
 	gt, err := payment.PaymentCurTime.GT(totalCost)
 	if err != nil {
 		return abciErrDecimal(err)
 	}
 	if gt {
-		return abciErrOverPayment
+		payment.Excess, err = payment.PaymentCurTime.Minus(totalCost)
+		if err != nil {
+			return abciErrDecimal(err)
+		}
+		fund, err = fund.Minus(payment.Excess)
+		if err != nil {
+			return abciErrDecimal(err)
+		}
 	}
 
-	//calculate and write changes to the set of all invoices
-	bal := payment.PaymentCurTime
+	//allocate the payment and write changes to the set of all invoices
+	if err := allocatePayment(payment, invoices, fund, rates, tx.Amounts, blockTime); err != nil {
+		return abciErrAllocation(err)
+	}
 	for _, invoice := range invoices {
-		//pay the funds to the invoice, reduce funds from bal
-		bal, err = invoice.GetCtx().Pay(bal)
-		if err != nil {
-			return abci.ErrUnauthorized.AppendLog("Error paying invoice: " + err.Error())
-		}
 		store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(*invoice))
 	}
+	storeCredits(store, payment.Receiver, payment.Sender, creditNotes)
+
+	if payment.Excess != nil {
+		if err := addBalance(store, payment.Sender, payment.Receiver, payment.Excess); err != nil {
+			return abciErrDecimal(err)
+		}
+	}
 
 	//add the payment object to the store
 	store.Set(PaymentKey(payment.TransactionID), wire.BinaryBytes(*payment))
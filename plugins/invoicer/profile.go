@@ -2,11 +2,13 @@ package invoicer
 
 import (
 	"bytes"
+	"strings"
 
 	abci "github.com/tendermint/abci/types"
 	btypes "github.com/tendermint/basecoin/types"
 	wire "github.com/tendermint/go-wire"
 
+	"github.com/tendermint/trackomatron/tax"
 	"github.com/tendermint/trackomatron/types"
 )
 
@@ -20,9 +22,27 @@ func validateProfile(profile *types.Profile) abci.Result {
 		return abci.ErrInternalError.AppendLog("new profile due duration must be non-negative")
 	case !profile.Active:
 		return abciErrProfileInactive
-	default:
-		return abci.OK
 	}
+	if _, err := types.GetCurrency(profile.AcceptedCur); err != nil {
+		return abciErrBadAmount(err)
+	}
+	if err := tax.ValidateJurisdiction(profile.TaxJurisdiction); err != nil {
+		return abciErrInternal(err)
+	}
+	if profile.Terms != nil {
+		if err := profile.Terms.Validate(); err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	if profile.LateFees != nil {
+		if err := profile.LateFees.Validate(); err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	if err := profile.ValidateSigners(); err != nil {
+		return abciErrInternal(err)
+	}
+	return abci.OK
 }
 
 func writeProfile(store btypes.KVStore, active []string, profile *types.Profile) abci.Result {
@@ -33,12 +53,29 @@ func writeProfile(store btypes.KVStore, active []string, profile *types.Profile)
 		return res
 	}
 
+	//each address controls a single profile
+	for _, address := range profile.Addresses() {
+		name, err := getNameFromAddress(store, address)
+		if err == nil && name != profile.Name {
+			return abciErrAddressTaken
+		}
+	}
+
+	//re-index the addresses of an edited profile
+	var old *types.Profile
+	if storeProfile, err := getProfile(store, profile.Name); err == nil {
+		old = &storeProfile
+	}
+	indexAddresses(store, old, profile)
+
 	//write the profile to the profile key
 	store.Set(ProfileKey(profile.Name), wire.BinaryBytes(*profile))
 
-	//add the profile name to the list of active profiles
-	active = append(active, profile.Name)
-	store.Set(ListProfileActiveKey(), wire.BinaryBytes(active))
+	//add the profile name to the list of active profiles, edited profiles are already listed
+	if !profileRegistered(active, profile.Name) {
+		active = append(active, profile.Name)
+		store.Set(ListProfileActiveKey(), wire.BinaryBytes(active))
+	}
 
 	return abci.OK
 }
@@ -57,6 +94,7 @@ func deactivateProfile(store btypes.KVStore, active []string, profile *types.Pro
 
 	storeProfile.Active = false
 	store.Set(ProfileKey(name), wire.BinaryBytes(storeProfile))
+	indexAddresses(store, &storeProfile, nil)
 
 	//remove profile from the list of active profiles
 	active = removeElemStringArray(active, name)
@@ -73,6 +111,23 @@ func deactivateProfile(store btypes.KVStore, active []string, profile *types.Pro
 	return abci.OK
 }
 
+// indexAddresses moves the address index from the addresses controlling the
+//   old profile to those controlling the new profile, either may be nil
+func indexAddresses(store btypes.KVStore, old, new *types.Profile) {
+	if old != nil {
+		for _, address := range old.Addresses() {
+			if new == nil || !new.Controls(address) {
+				store.Set(AddressKey(address), nil)
+			}
+		}
+	}
+	if new != nil {
+		for _, address := range new.Addresses() {
+			store.Set(AddressKey(address), wire.BinaryBytes(new.Name))
+		}
+	}
+}
+
 //TODO move to tmlibs/common
 func removeElemStringArray(a []string, remove string) []string {
 	for i, el := range a {
@@ -93,18 +148,25 @@ func profileRegistered(active []string, name string) bool {
 	return false
 }
 
-func nameFromAddress(store btypes.KVStore, active []string, address []byte) string {
-	for _, name := range active {
-		profile, _ := getProfile(store, name)
-		if bytes.Compare(profile.Address, address) == 0 {
-			return profile.Name
-		}
+// authenticate checks that an address provided within a tx, if any, is
+//   the address which signed the tx
+func authenticate(txAddr, callerAddr []byte) abci.Result {
+	if len(callerAddr) == 0 {
+		return abciErrNotOwner("tx has no signer")
 	}
-	return ""
+	if len(txAddr) > 0 && !bytes.Equal(txAddr, callerAddr) {
+		return abciErrNotOwner("tx address does not match the signer")
+	}
+	return abci.OK
+}
+
+func nameFromAddress(store btypes.KVStore, address []byte) string {
+	name, _ := getNameFromAddress(store, address)
+	return name
 }
 
 // ProfileTx Generates the tendermint TX used by the light and heavy client
-func runTxProfile(store btypes.KVStore, txBytes []byte) abci.Result {
+func runTxProfile(store btypes.KVStore, callerAddr []byte, txBytes []byte) abci.Result {
 
 	tb := txBytes[0]
 
@@ -115,12 +177,73 @@ func runTxProfile(store btypes.KVStore, txBytes []byte) abci.Result {
 		return abciErrDecodingTX(err)
 	}
 
+	var terms *types.PaymentTerms
+	if len(tx.Terms) > 0 {
+		terms, err = types.ParsePaymentTerms(tx.Terms)
+		if err != nil {
+			return abciErrInternal(err)
+		}
+	}
+
+	var lateFees *types.LateFeePolicy
+	if len(tx.LateFees) > 0 {
+		lateFees, err = types.ParseLateFeePolicy(tx.LateFees)
+		if err != nil {
+			return abciErrInternal(err)
+		}
+	}
+
+	var limit *types.AmtCurTime
+	if len(tx.Limit) > 0 {
+		blockTime, err := getBlockTime(store)
+		if err != nil {
+			return abciErrInternal(err)
+		}
+		limit, err = types.ParseAmtCurTime(tx.Limit, blockTime)
+		if err != nil {
+			return abciErrBadAmount(err)
+		}
+	}
+
+	//the profile address is always the signer's
+	res := authenticate(tx.Address, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+
+	//changes to a multi-signature profile need the threshold of signers
+	if tb != TBTxProfileOpen {
+		var storeProfile *types.Profile
+		if len(tx.Name) > 0 {
+			p, err := getProfile(store, tx.Name)
+			if err == nil {
+				storeProfile = &p
+			}
+		} else {
+			storeProfile, _ = getProfileFromAddress(store, callerAddr)
+		}
+		if storeProfile != nil && storeProfile.Controls(callerAddr) && storeProfile.RequiresSignatures(nil) {
+			ready, res := collectSignatures(store, storeProfile, callerAddr, txBytes)
+			if !ready {
+				return res
+			}
+		}
+	}
+
 	profile := types.NewProfile(
-		tx.Address,
+		callerAddr,
 		tx.Name,
 		tx.AcceptedCur,
 		tx.DepositInfo,
 		tx.DueDurationDays,
+		strings.ToUpper(tx.TaxJurisdiction),
+		tx.TaxID,
+		terms,
+		lateFees,
+		tx.TrustedSenders,
+		tx.Signers,
+		tx.Threshold,
+		limit,
 	)
 
 	switch tb {
@@ -143,7 +266,7 @@ func runActionProfile(store btypes.KVStore, profile *types.Profile, shouldExist
 		return abciErrGetProfiles
 	}
 	if len(profile.Name) == 0 {
-		profile.Name = nameFromAddress(store, active, profile.Address)
+		profile.Name = nameFromAddress(store, profile.Address)
 	}
 
 	//Check existence
@@ -154,5 +277,147 @@ func runActionProfile(store btypes.KVStore, profile *types.Profile, shouldExist
 		return abciErrProfileExists
 	}
 
+	//the names of deactivated profiles are reserved for their reactivation
+	if !shouldExist {
+		inactive, err := getListString(store, ListProfileInactiveKey())
+		if err != nil {
+			return abciErrGetAllProfiles
+		}
+		if profileRegistered(inactive, profile.Name) {
+			return abciErrProfileReserved
+		}
+	}
+
+	//only the owner may modify an existing profile
+	if shouldExist {
+		storeProfile, err := getProfile(store, profile.Name)
+		if err != nil {
+			return abciErrNoProfile
+		}
+		if !storeProfile.Controls(profile.Address) {
+			return abciErrNotOwner("profile " + profile.Name + " is owned by another address")
+		}
+		profile.Address = storeProfile.Address
+
+		//members are managed through the org txs
+		profile.Members = storeProfile.Members
+		profile.ApprovalChain = storeProfile.ApprovalChain
+	}
+
+	//the profile address is always one of the signers of a multi-signature profile
+	if len(profile.Signers) > 0 && !types.BytesIn(profile.Signers, profile.Address) {
+		profile.Signers = append([][]byte{profile.Address}, profile.Signers...)
+	}
+
 	return action(store, active, profile)
 }
+
+func runTxProfileReactivate(store btypes.KVStore, callerAddr []byte, txBytes []byte) abci.Result {
+
+	// Decode tx
+	var tx = new(types.TxProfile)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res := authenticate(tx.Address, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+
+	//only the owner may reactivate a deactivated profile
+	inactive, err := getListString(store, ListProfileInactiveKey())
+	if err != nil {
+		return abciErrGetAllProfiles
+	}
+	if !profileRegistered(inactive, tx.Name) {
+		return abci.ErrInternalError.AppendLog("Cannot reactivate a profile which is not deactivated")
+	}
+	profile, err := getProfile(store, tx.Name)
+	if err != nil {
+		return abciErrNoProfile
+	}
+	if !profile.Controls(callerAddr) {
+		return abciErrNotOwner("profile " + profile.Name + " is owned by another address")
+	}
+	if profile.RequiresSignatures(nil) {
+		ready, res := collectSignatures(store, &profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	active, err := getListString(store, ListProfileActiveKey())
+	if err != nil {
+		return abciErrGetProfiles
+	}
+	for _, address := range profile.Addresses() {
+		if len(nameFromAddress(store, address)) > 0 {
+			return abciErrAddressTaken
+		}
+	}
+	profile.Active = true
+	store.Set(ProfileKey(profile.Name), wire.BinaryBytes(profile))
+	indexAddresses(store, nil, &profile)
+	store.Set(ListProfileActiveKey(), wire.BinaryBytes(append(active, profile.Name)))
+	inactive = removeElemStringArray(inactive, profile.Name)
+	store.Set(ListProfileInactiveKey(), wire.BinaryBytes(inactive))
+	return abci.OK
+}
+
+func runTxProfileRotateKey(store btypes.KVStore, callerAddr []byte, txBytes []byte) abci.Result {
+
+	// Decode tx
+	var tx = new(types.TxProfileRotateKey)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	//the tx signed by the old key is the proof of ownership
+	res := authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	active, err := getListString(store, ListProfileActiveKey())
+	if err != nil {
+		return abciErrGetProfiles
+	}
+	name := tx.Name
+	if len(name) == 0 {
+		name = nameFromAddress(store, callerAddr)
+	}
+	if !profileRegistered(active, name) {
+		return abciErrProfileNonExistent
+	}
+	profile, err := getProfile(store, name)
+	if err != nil {
+		return abciErrNoProfile
+	}
+	if !profile.Controls(callerAddr) {
+		return abciErrNotOwner("profile " + profile.Name + " is owned by another address")
+	}
+
+	//the new address must not already control a profile
+	if len(nameFromAddress(store, tx.NewAddress)) > 0 {
+		return abciErrAddressTaken
+	}
+	if profile.RequiresSignatures(nil) {
+		ready, res := collectSignatures(store, &profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	err = profile.RotateKey(callerAddr, tx.NewAddress)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	store.Set(ProfileKey(profile.Name), wire.BinaryBytes(profile))
+
+	//move the index from the old key to the new address
+	store.Set(AddressKey(callerAddr), nil)
+	store.Set(AddressKey(tx.NewAddress), wire.BinaryBytes(profile.Name))
+	return abci.OK
+}
@@ -0,0 +1,169 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestProfileOwnership(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := btypes.NewMemKVStore()
+	owner, other := []byte("owner"), []byte("other")
+
+	tx := types.TxProfile{Name: "foo", AcceptedCur: "BTC", DueDurationDays: 14}
+	res := runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+
+	//the profile is registered to the signer
+	profile, err := getProfile(store, "foo")
+	require.Nil(err)
+	assert.Equal(owner, profile.Address)
+
+	//another signer may not edit or deactivate the profile
+	tx.DepositInfo = "stolen"
+	res = runTxProfile(store, other, MarshalWithTB(tx, TBTxProfileEdit))
+	assert.Equal(CodeTypeNotOwner, res.Code, res.Log)
+	res = runTxProfile(store, other, MarshalWithTB(tx, TBTxProfileDeactivate))
+	assert.Equal(CodeTypeNotOwner, res.Code, res.Log)
+
+	//nor claim the owner's address in the tx
+	tx.Address = owner
+	res = runTxProfile(store, other, MarshalWithTB(tx, TBTxProfileEdit))
+	assert.Equal(CodeTypeNotOwner, res.Code, res.Log)
+
+	//invoices are sent from the signer's profile
+	txInv := types.TxInvoice{Amount: "1BTC", SenderAddr: owner, To: "foo"}
+	res = runTxInvoice(store, other, MarshalWithTB(txInv, TBTxContractOpen))
+	assert.Equal(CodeTypeNotOwner, res.Code, res.Log)
+}
+
+func TestProfileReactivateRotateKey(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := btypes.NewMemKVStore()
+	owner, other, newKey := []byte("owner"), []byte("other"), []byte("newkey")
+
+	tx := types.TxProfile{Name: "foo", AcceptedCur: "BTC", DueDurationDays: 14}
+	res := runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+	res = runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileDeactivate))
+	require.True(res.IsOK(), res.Log)
+
+	//the name of a deactivated profile is reserved
+	res = runTxProfile(store, other, MarshalWithTB(tx, TBTxProfileOpen))
+	assert.Equal(abciErrProfileReserved, res)
+
+	//only the owner may reactivate the profile
+	reactivate := func(addr []byte) abci.Result {
+		tx := types.TxProfile{Name: "foo", Address: addr}
+		return runTxProfileReactivate(store, addr, MarshalWithTB(tx, TBTxProfileReactivate))
+	}
+	assert.Equal(CodeTypeNotOwner, reactivate(other).Code)
+	res = reactivate(owner)
+	require.True(res.IsOK(), res.Log)
+	profile, err := getProfile(store, "foo")
+	require.Nil(err)
+	assert.True(profile.Active)
+	assert.True(reactivate(owner).IsErr())
+
+	//the key is rotated with a tx signed by the old key
+	rotate := func(addr, newAddr []byte) abci.Result {
+		tx := types.TxProfileRotateKey{Name: "foo", SenderAddr: addr, NewAddress: newAddr}
+		return runTxProfileRotateKey(store, addr, MarshalWithTB(tx, TBTxProfileRotateKey))
+	}
+	assert.Equal(CodeTypeNotOwner, rotate(other, other).Code)
+	assert.True(rotate(owner, nil).IsErr())
+	res = rotate(owner, newKey)
+	require.True(res.IsOK(), res.Log)
+	profile, err = getProfile(store, "foo")
+	require.Nil(err)
+	assert.Equal(newKey, profile.Address)
+
+	//the old key no longer controls the profile
+	assert.Equal(CodeTypeNotOwner, rotate(owner, other).Code)
+	tx.DepositInfo = "moved"
+	res = runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileEdit))
+	assert.Equal(CodeTypeNotOwner, res.Code, res.Log)
+	res = runTxProfile(store, newKey, MarshalWithTB(tx, TBTxProfileEdit))
+	require.True(res.IsOK(), res.Log)
+
+	//keys cannot be rotated to an address which controls another profile
+	res = runTxProfile(store, other, MarshalWithTB(
+		types.TxProfile{Name: "bar", AcceptedCur: "BTC", DueDurationDays: 14}, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+	assert.True(rotate(newKey, other).IsErr())
+}
+
+func TestProfileAddressIndex(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := btypes.NewMemKVStore()
+	owner, signer, other := []byte("owner"), []byte("signer"), []byte("other")
+	indexed := func(address []byte) string {
+		name, _ := getNameFromAddress(store, address)
+		return name
+	}
+
+	tx := types.TxProfile{Name: "foo", AcceptedCur: "BTC", DueDurationDays: 14}
+	res := runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("foo", indexed(owner))
+	profile, err := getProfileFromAddress(store, owner)
+	require.Nil(err)
+	assert.Equal("foo", profile.Name)
+
+	//signers are indexed when added and removed from the index when dropped
+	tx.Signers = [][]byte{signer}
+	res = runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileEdit))
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("foo", indexed(signer))
+	tx.Signers = nil
+	res = runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileEdit))
+	require.True(res.IsOK(), res.Log)
+	assert.Empty(indexed(signer))
+	assert.Equal("foo", indexed(owner))
+
+	//edits keep a single entry in the list of active profiles
+	active, err := getListString(store, ListProfileActiveKey())
+	require.Nil(err)
+	assert.Equal([]string{"foo"}, active)
+
+	//an address controls a single profile
+	txBar := types.TxProfile{Name: "bar", AcceptedCur: "BTC", DueDurationDays: 14}
+	res = runTxProfile(store, owner, MarshalWithTB(txBar, TBTxProfileOpen))
+	assert.Equal(abciErrAddressTaken, res)
+	res = runTxProfile(store, other, MarshalWithTB(txBar, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+	tx.Signers = [][]byte{other}
+	res = runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileEdit))
+	assert.Equal(abciErrAddressTaken, res)
+
+	//deactivation removes the addresses from the index, reactivation restores them
+	tx.Signers = nil
+	res = runTxProfile(store, owner, MarshalWithTB(tx, TBTxProfileDeactivate))
+	require.True(res.IsOK(), res.Log)
+	assert.Empty(indexed(owner))
+	_, err = getProfileFromAddress(store, owner)
+	assert.NotNil(err)
+	res = runTxProfileReactivate(store, owner, MarshalWithTB(
+		types.TxProfile{Name: "foo", Address: owner}, TBTxProfileReactivate))
+	require.True(res.IsOK(), res.Log)
+	assert.Equal("foo", indexed(owner))
+
+	//key rotation moves the index to the new address
+	res = runTxProfileRotateKey(store, owner, MarshalWithTB(
+		types.TxProfileRotateKey{SenderAddr: owner, NewAddress: signer}, TBTxProfileRotateKey))
+	require.True(res.IsOK(), res.Log)
+	assert.Empty(indexed(owner))
+	assert.Equal("foo", indexed(signer))
+}
@@ -0,0 +1,170 @@
+package invoicer
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/common"
+	"github.com/tendermint/trackomatron/types"
+)
+
+func validateRate(rate *types.ExchangeRate) abci.Result {
+	switch {
+	case len(rate.From) == 0 || len(rate.To) == 0:
+		return abci.ErrInternalError.AppendLog("rate must have a currency pair")
+	case strings.Contains(rate.From, "/") || strings.Contains(rate.To, "/"):
+		return abci.ErrInternalError.AppendLog("rate currencies cannot contain '/'")
+	case rate.From == rate.To:
+		return abci.ErrInternalError.AppendLog("rate currency pair must be two different currencies")
+	case rate.Date.IsZero():
+		return abci.ErrInternalError.AppendLog("rate must have a date")
+	}
+	for _, cur := range []string{rate.From, rate.To} {
+		if _, err := types.GetCurrency(cur); err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	dec, err := decimal.NewFromString(rate.Rate)
+	if err != nil {
+		return abciErrDecimal(err)
+	}
+	if dec.Sign() <= 0 {
+		return abci.ErrInternalError.AppendLog("rate must be positive")
+	}
+	return abci.OK
+}
+
+func isOracle(store btypes.KVStore, address []byte) (bool, error) {
+	oracles, err := getListBytes(store, ListOracleKey())
+	if err != nil {
+		return false, err
+	}
+	for _, oracle := range oracles {
+		if bytes.Compare(oracle, address) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func addOracle(store btypes.KVStore, address []byte) error {
+	registered, err := isOracle(store, address)
+	if err != nil || registered {
+		return err
+	}
+	oracles, err := getListBytes(store, ListOracleKey())
+	if err != nil {
+		return err
+	}
+	oracles = append(oracles, address)
+	store.Set(ListOracleKey(), wire.BinaryBytes(oracles))
+	return nil
+}
+
+func runTxRate(store btypes.KVStore, callerAddr []byte, txBytes []byte) abci.Result {
+
+	// Decode tx
+	var tx = new(types.TxRate)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	//only the oracles set through the genesis may post rates
+	oracle, err := isOracle(store, callerAddr)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	if !oracle {
+		return abciErrNotOracle
+	}
+
+	date, err := time.Parse(common.TimeLayout, tx.Date)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	//currency codes are case-insensitive, as in amounts
+	rate := &types.ExchangeRate{
+		From: strings.ToUpper(tx.From),
+		To:   strings.ToUpper(tx.To),
+		Date: date,
+		Rate: tx.Rate,
+	}
+
+	//Validate
+	res := validateRate(rate)
+	if res.IsErr() {
+		return res
+	}
+
+	//add the pair to the list of pairs posted for the date
+	if _, err := getRate(store, rate.From, rate.To, rate.Date); err != nil {
+		pairs, err := getListString(store, ListRateKey(rate.Date))
+		if err != nil {
+			return abciErrInternal(err)
+		}
+		pairs = append(pairs, rate.From+"/"+rate.To)
+		store.Set(ListRateKey(rate.Date), wire.BinaryBytes(pairs))
+	}
+
+	store.Set(RateKey(rate.From, rate.To, rate.Date), wire.BinaryBytes(*rate))
+	return abci.OK
+}
+
+// storeRates is a RateProvider which only reads the rates posted to the store
+type storeRates struct {
+	store btypes.KVStore
+}
+
+var _ common.RateProvider = storeRates{}
+
+// Rates implements common.RateProvider
+func (s storeRates) Rates(date time.Time) (rates []types.ExchangeRate, err error) {
+	pairs, err := getListString(s.store, ListRateKey(date))
+	if err != nil {
+		return
+	}
+	for _, pair := range pairs {
+		curs := strings.Split(pair, "/")
+		rate, err := getRate(s.store, curs[0], curs[1], date)
+		if err != nil {
+			return rates, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// convertAmtCurTime converts an AmtCurTime to the denomOut valuation
+//   using only the exchange rates which have been posted to the store
+func convertAmtCurTime(store btypes.KVStore, denomOut string,
+	in *types.AmtCurTime) (*types.AmtCurTime, *types.Conversion, error) {
+
+	out, conversion, err := common.ConvertAmtCurTime(storeRates{store}, denomOut, in)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "no exchange rate posted for %v/%v on %v",
+			in.CurTime.Cur, denomOut, in.CurTime.Date.Format(common.TimeLayout))
+	}
+	return out, conversion, nil
+}
+
+// paymentRate retrieves the value of one unit of the payment currency in the
+//   payable currency on the date using only the exchange rates posted to the store
+func paymentRate(store btypes.KVStore, payCur, payableCur string, date time.Time) (string, error) {
+	if payCur == payableCur {
+		return "1", nil
+	}
+	rate, _, err := common.ConversionRate(storeRates{store}, payCur, payableCur, date)
+	if err != nil {
+		return "", errors.Wrapf(err, "no exchange rate posted for %v/%v on %v",
+			payCur, payableCur, date.Format(common.TimeLayout))
+	}
+	return rate.String(), nil
+}
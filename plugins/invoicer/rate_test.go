@@ -0,0 +1,163 @@
+package invoicer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestRunTxRate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := btypes.NewMemKVStore()
+	oracle, other := []byte("oracle"), []byte("other")
+	require.Nil(addOracle(store, oracle))
+
+	tx := types.TxRate{From: "BTC", To: "USD", Rate: "430.5", Date: "2015-12-31"}
+	txBytes := MarshalWithTB(tx, TBTxRate)
+
+	//only the oracle may post
+	res := runTxRate(store, other, txBytes)
+	assert.True(res.IsErr())
+	res = runTxRate(store, oracle, txBytes)
+	assert.True(res.IsOK(), res.Log)
+
+	//bad rates are rejected
+	for _, bad := range []types.TxRate{
+		{From: "BTC", To: "BTC", Rate: "1", Date: "2015-12-31"},
+		{From: "btc", To: "BTC", Rate: "1", Date: "2015-12-31"},
+		{From: "FOO", To: "USD", Rate: "1", Date: "2015-12-31"},
+		{From: "BTC", To: "USD", Rate: "-1", Date: "2015-12-31"},
+		{From: "BTC", To: "USD", Rate: "abc", Date: "2015-12-31"},
+		{From: "BTC", To: "USD", Rate: "1", Date: "31-12-2015"},
+	} {
+		res = runTxRate(store, oracle, MarshalWithTB(bad, TBTxRate))
+		assert.True(res.IsErr(), "%v", bad)
+	}
+
+	//currency codes are stored in upper case
+	tx = types.TxRate{From: "eth", To: "usd", Rate: "10", Date: "2015-12-31"}
+	res = runTxRate(store, oracle, MarshalWithTB(tx, TBTxRate))
+	require.True(res.IsOK(), res.Log)
+	_, err := getRate(store, "ETH", "USD", time.Date(2015, time.Month(12), 31, 0, 0, 0, 0, time.UTC))
+	assert.Nil(err)
+}
+
+func TestConvertStoredRate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	date := time.Date(2015, time.Month(12), 31, 0, 0, 0, 0, time.UTC)
+	store := btypes.NewMemKVStore()
+	oracle := []byte("oracle")
+	require.Nil(addOracle(store, oracle))
+	tx := types.TxRate{From: "BTC", To: "USD", Rate: "400", Date: "2015-12-31"}
+	res := runTxRate(store, oracle, MarshalWithTB(tx, TBTxRate))
+	require.True(res.IsOK(), res.Log)
+
+	var testConv = []struct {
+		in       string
+		denomOut string
+		out      string
+		errNil   bool
+	}{
+		{"2BTC", "USD", "800", true},
+		{"200USD", "BTC", "0.5", true},
+		{"200USD", "USD", "200", true},
+		{"200USD", "EUR", "", false},
+	}
+
+	for _, test := range testConv {
+		in, err := types.ParseAmtCurTime(test.in, date)
+		require.Nil(err)
+		out, _, err := convertAmtCurTime(store, test.denomOut, in)
+		if !test.errNil {
+			assert.NotNil(err)
+			continue
+		}
+		require.Nil(err, test.in)
+		expected, err := types.ParseAmtCurTime(test.out+test.denomOut, date)
+		require.Nil(err)
+		eq, err := out.EQ(expected)
+		assert.Nil(err)
+		assert.True(eq, "%v to %v got %v", test.in, test.denomOut, out.Amount)
+	}
+
+	//convert through multiple posted rates, recording the path
+	tx = types.TxRate{From: "EUR", To: "USD", Rate: "1.25", Date: "2015-12-31"}
+	res = runTxRate(store, oracle, MarshalWithTB(tx, TBTxRate))
+	require.True(res.IsOK(), res.Log)
+	in, err := types.ParseAmtCurTime("800EUR", date)
+	require.Nil(err)
+	out, conversion, err := convertAmtCurTime(store, "BTC", in)
+	require.Nil(err)
+	assert.Equal("2.5", out.Amount)
+	assert.Equal([]string{"EUR", "USD", "BTC"}, conversion.Path)
+
+	//no rate for another date
+	in, err = types.ParseAmtCurTime("2BTC", date.AddDate(0, 0, 1))
+	require.Nil(err)
+	_, _, err = convertAmtCurTime(store, "USD", in)
+	assert.NotNil(err)
+}
+
+func TestCrossCurrencyPayment(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := btypes.NewMemKVStore()
+	date := time.Date(2017, time.Month(1), 31, 0, 0, 0, 0, time.UTC)
+	New().BeginBlock(store, nil, &abci.Header{Time: uint64(date.Unix())})
+	oracle := []byte("oracle")
+	require.Nil(addOracle(store, oracle))
+	tx := types.TxRate{From: "BTC", To: "USD", Rate: "1000", Date: "2017-01-31"}
+	res := runTxRate(store, oracle, MarshalWithTB(tx, TBTxRate))
+	require.True(res.IsOK(), res.Log)
+
+	sender, receiver := []byte("sender"), []byte("receiver")
+	res = runTxProfile(store, sender, MarshalWithTB(
+		types.TxProfile{Name: "foo", AcceptedCur: "BTC", DueDurationDays: 14}, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+	res = runTxProfile(store, receiver, MarshalWithTB(
+		types.TxProfile{Name: "bar", AcceptedCur: "USD", DueDurationDays: 14}, TBTxProfileOpen))
+	require.True(res.IsOK(), res.Log)
+
+	txInvoice := types.TxInvoice{Amount: "0.01234567BTC", SenderAddr: sender, To: "bar", Notes: "fx"}
+	res = runTxInvoice(store, sender, MarshalWithTB(txInvoice, TBTxContractOpen))
+	require.True(res.IsOK(), res.Log)
+	ids, err := getListBytes(store, ListInvoiceKey())
+	require.Nil(err)
+	id := ids[0]
+	pay := func(txID, amt string) abci.Result {
+		fund, err := types.ParseAmtCurTime(amt, date)
+		require.Nil(err)
+		tx := types.TxPayment{TransactionID: txID, SenderAddr: receiver, IDs: [][]byte{id},
+			Receiver: "foo", Amt: fund, DateRange: ":"}
+		return runTxPayment(store, receiver, MarshalWithTB(tx, TBTxPayment))
+	}
+
+	//a currency without a posted rate cannot be used
+	assert.True(pay("tx1", "10EUR").IsErr())
+
+	//the payment is converted at the posted rate, the rounding is a realized gain
+	res = pay("tx2", "12.35USD")
+	require.True(res.IsOK(), res.Log)
+	invoice, err := getInvoice(store, id)
+	require.Nil(err)
+	ctx := invoice.GetCtx()
+	assert.False(ctx.Open)
+	require.Len(ctx.Allocations, 1)
+	assert.Equal("0.01235", ctx.Allocations[0].Amount.Amount)
+	assert.Equal("0.001", ctx.Allocations[0].Rate)
+	assert.Equal("0.00000433", ctx.Allocations[0].FXGainLoss.Amount)
+	payment, err := getPayment(store, []byte("tx2"))
+	require.Nil(err)
+	assert.Nil(payment.Excess)
+}
@@ -0,0 +1,129 @@
+package invoicer
+
+import (
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func runTxPaymentReverse(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	tb := txBytes[0]
+
+	// Decode tx
+	var tx = new(types.TxPaymentReverse)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
+	if err != nil {
+		return abciErrNoSender
+	}
+
+	//Validate Tx
+	kind := types.ReversalBounced
+	if tb == TBTxRefund {
+		kind = types.ReversalRefund
+	}
+	switch {
+	case len(tx.Reason) == 0:
+		return abci.ErrInternalError.AppendLog("A reason is required to reverse a payment")
+	case kind == types.ReversalRefund && len(tx.RefundID) == 0:
+		return abci.ErrInternalError.AppendLog("A refund must include the transaction ID of the refund")
+	}
+
+	//only the receiver of the funds may reverse the payment
+	payment, err := getPayment(store, []byte(tx.TransactionID))
+	if err != nil {
+		return abciErrPaymentMissing
+	}
+	if payment.Reversed {
+		return abciErrPaymentReversed
+	}
+	if payment.Receiver != profile.Name {
+		return abciErrNotOwner("payment was received by another profile")
+	}
+
+	//large reversals by a multi-signature profile need the threshold of signers
+	if profile.RequiresSignatures(payment.PaymentCurTime) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	reversal := types.Reversal{
+		TransactionID: payment.TransactionID,
+		Kind:          kind,
+		RefundID:      tx.RefundID,
+		Sender:        profile.Name,
+		Reason:        tx.Reason,
+		Date:          blockTime,
+	}
+
+	//withdraw the excess held as credit, which must not yet have been applied
+	var balance types.Balance
+	if payment.Excess != nil {
+		balance, err = getBalance(store, payment.Sender, payment.Receiver)
+		if err != nil {
+			return abciErrInternal(err)
+		}
+		lt, err := balance.Credit.LT(payment.Excess)
+		if err != nil {
+			return abciErrDecimal(err)
+		}
+		if lt {
+			return abci.ErrUnauthorized.AppendLog(
+				"Cannot reverse a payment once the credit from its excess has been applied")
+		}
+		balance.Credit, err = balance.Credit.Minus(payment.Excess)
+		if err != nil {
+			return abciErrDecimal(err)
+		}
+	}
+
+	//unwind the allocations of the payment, reopening the invoices
+	var invoices []types.Invoice
+	for _, allocated := range payment.Allocated {
+		invoice, err := getInvoice(store, allocated.InvoiceID)
+		if err != nil {
+			return abciErrInvoiceMissing
+		}
+		err = invoice.GetCtx().Unpay(payment.TransactionID, blockTime)
+		if err != nil {
+			return abciErrDecimal(err)
+		}
+		invoices = append(invoices, invoice)
+		reversal.Unwound = append(reversal.Unwound, allocated)
+	}
+
+	for _, invoice := range invoices {
+		store.Set(InvoiceKey(invoice.GetID()), wire.BinaryBytes(invoice))
+
+		//reopened invoices may again accrue late charges
+		if invoice.GetCtx().LateFees != nil {
+			if err := addAccrual(store, invoice.GetID()); err != nil {
+				return abciErrInternal(err)
+			}
+		}
+	}
+	if payment.Excess != nil {
+		store.Set(BalanceKey(balance.Sender, balance.Receiver), wire.BinaryBytes(balance))
+	}
+	payment.Reversed = true
+	store.Set(PaymentKey(payment.TransactionID), wire.BinaryBytes(payment))
+	store.Set(ReversalKey(payment.TransactionID), wire.BinaryBytes(reversal))
+	return abci.OK
+}
@@ -0,0 +1,81 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestPaymentReverse(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar()
+	sender, receiver := f.sender, f.receiver
+
+	openInvoice := func(amt, notes string) []byte {
+		return f.openInvoiceTx(types.TxInvoice{Amount: amt, Notes: notes, Terms: "2/10 net 30"})
+	}
+	pay := f.pay
+	reverse := func(addr []byte, tb byte, txID, refundID, reason string) abci.Result {
+		tx := types.TxPaymentReverse{TransactionID: txID, SenderAddr: addr, RefundID: refundID, Reason: reason}
+		return runTxPaymentReverse(f.store, addr, MarshalWithTB(tx, tb))
+	}
+	ctx := f.ctx
+
+	//settle two invoices within the discount window
+	first := openInvoice("100USD", "first")
+	second := openInvoice("50USD", "second")
+	res := pay("tx1", "148USD", first, second)
+	require.True(res.IsOK(), res.Log)
+	assert.False(ctx(first).Open)
+	assert.False(ctx(second).Open)
+	assert.True(pay("tx1", "1USD").IsErr()) //duplicate transaction ID
+	ledger := len(ctx(first).Allocations)
+
+	//only the payee may reverse, with a reason, refunds need the refund transaction
+	assert.Equal(CodeTypeNotOwner, reverse(receiver, TBTxPaymentReverse, "tx1", "", "bounced").Code)
+	assert.True(reverse(sender, TBTxPaymentReverse, "tx1", "", "").IsErr())
+	assert.True(reverse(sender, TBTxRefund, "tx1", "", "returned").IsErr())
+	assert.True(reverse(sender, TBTxPaymentReverse, "nope", "", "bounced").IsErr())
+
+	//the bounced payment is unwound, the invoices reopen and the discount is withdrawn
+	res = reverse(sender, TBTxPaymentReverse, "tx1", "", "bounced")
+	require.True(res.IsOK(), res.Log)
+	for _, id := range [][]byte{first, second} {
+		assert.True(ctx(id).Open)
+		assert.Nil(ctx(id).Discount)
+		assert.Equal("0", f.paid(id))
+	}
+	assert.Equal(ledger+1, len(ctx(first).Allocations))
+	assert.Equal("-98", ctx(first).Allocations[ledger].Amount.Amount)
+
+	payment, err := getPayment(f.store, []byte("tx1"))
+	require.Nil(err)
+	assert.True(payment.Reversed)
+	reversal, err := getReversal(f.store, "tx1")
+	require.Nil(err)
+	assert.Equal(types.ReversalBounced, reversal.Kind)
+	assert.Equal("bounced", reversal.Reason)
+	assert.Equal(2, len(reversal.Unwound))
+	assert.Equal(abciErrPaymentReversed, reverse(sender, TBTxRefund, "tx1", "r1", "again"))
+
+	//a refund withdraws the excess held as credit
+	res = pay("tx2", "160USD", first, second)
+	require.True(res.IsOK(), res.Log)
+	res = reverse(sender, TBTxRefund, "tx2", "refund1", "returned")
+	require.True(res.IsOK(), res.Log)
+	reversal, err = getReversal(f.store, "tx2")
+	require.Nil(err)
+	assert.Equal(types.ReversalRefund, reversal.Kind)
+	assert.Equal("refund1", reversal.RefundID)
+	balance, err := getBalance(f.store, "bar", "foo")
+	require.Nil(err)
+	assert.Equal("0", balance.Credit.Amount)
+	assert.True(ctx(first).Open)
+}
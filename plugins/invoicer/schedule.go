@@ -0,0 +1,281 @@
+package invoicer
+
+import (
+	"bytes"
+	"time"
+
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-wire"
+
+	"github.com/tendermint/trackomatron/common"
+	"github.com/tendermint/trackomatron/types"
+)
+
+// validateScheduleTemplate checks the template invoice is well formed, the
+//   amounts are valued and converted when each invoice is issued
+func validateScheduleTemplate(tx *types.TxInvoice, start time.Time) abci.Result {
+	switch {
+	case len(tx.EditID) > 0:
+		return abci.ErrInternalError.AppendLog("schedule cannot edit an existing invoice")
+	case len(tx.Amount) == 0 && len(tx.LineItems) == 0:
+		return abci.ErrInternalError.AppendLog("schedule must have an amount or line items")
+	}
+	if len(tx.Amount) > 0 {
+		if _, err := types.ParseAmtCurTime(tx.Amount, start); err != nil {
+			return abciErrBadAmount(err)
+		}
+	}
+	if len(tx.LineItems) > 0 {
+		items, err := types.ParseLineItems(tx.LineItems, start)
+		if err != nil {
+			return abciErrBadAmount(err)
+		}
+		if _, err = types.SumLineItems(items); err != nil {
+			return abciErrBadAmount(err)
+		}
+	}
+	if len(tx.Terms) > 0 {
+		if _, err := types.ParsePaymentTerms(tx.Terms); err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	if len(tx.LateFees) > 0 {
+		if _, err := types.ParseLateFeePolicy(tx.LateFees); err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	return abci.OK
+}
+
+func runTxScheduleOpen(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	// Decode tx
+	var tx = new(types.TxScheduleOpen)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	//get the sender's profile from the signer's address
+	res = authenticate(tx.Invoice.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
+	if err != nil {
+		return abciErrNoSender
+	}
+	if _, err := getProfile(store, tx.Invoice.To); err != nil {
+		return abciErrNoReceiver
+	}
+
+	cadence, err := common.ParseCadence(tx.Cadence)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+
+	//the schedule starts from the current block unless specified
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	start := blockTime
+	if len(tx.Start) > 0 {
+		start, err = time.Parse(common.TimeLayout, tx.Start)
+		if err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	var end time.Time
+	if len(tx.End) > 0 {
+		end, err = time.Parse(common.TimeLayout, tx.End)
+		if err != nil {
+			return abciErrInternal(err)
+		}
+	}
+	switch {
+	case tx.Occurrences < 0:
+		return abci.ErrInternalError.AppendLog("schedule occurrences cannot be negative")
+	case start.Before(blockTime.Truncate(24 * time.Hour)):
+		return abci.ErrInternalError.AppendLog("schedule cannot start before the current block")
+	case !end.IsZero() && end.Before(start):
+		return abci.ErrInternalError.AppendLog("schedule cannot end before it starts")
+	}
+
+	res = validateScheduleTemplate(&tx.Invoice, start)
+	if res.IsErr() {
+		return res
+	}
+
+	//schedules issue invoices without limit, they always wait for the threshold of signers
+	if profile.RequiresSignatures(nil) {
+		ready, res := collectSignatures(store, profile, callerAddr, txBytes)
+		if !ready {
+			return res
+		}
+	}
+
+	//the dates of each invoice are determined by the schedule
+	template := tx.Invoice
+	template.Date, template.DueDate = "", ""
+
+	schedule := types.NewSchedule(profile.Name, tx.Invoice.To, &template, tx.Cadence,
+		start, end, tx.Occurrences, cadence.Next(start, start.Add(-time.Nanosecond)))
+	if schedule.Finished() {
+		return abci.ErrInternalError.AppendLog("schedule has no occurrences")
+	}
+	schedule.SetID()
+	if _, err := getSchedule(store, schedule.ID); err == nil {
+		return abciErrDupSchedule
+	}
+
+	schedules, err := getListBytes(store, ListScheduleKey())
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	store.Set(ScheduleKey(schedule.ID), wire.BinaryBytes(*schedule))
+	schedules = append(schedules, schedule.ID)
+	store.Set(ListScheduleKey(), wire.BinaryBytes(schedules))
+
+	return abci.NewResultOK(schedule.ID, "")
+}
+
+func runTxScheduleCancel(store btypes.KVStore, callerAddr []byte, txBytes []byte) (res abci.Result) {
+
+	// Decode tx
+	var tx = new(types.TxScheduleCancel)
+	err := wire.ReadBinaryBytes(txBytes[1:], tx)
+	if err != nil {
+		return abciErrDecodingTX(err)
+	}
+
+	res = authenticate(tx.SenderAddr, callerAddr)
+	if res.IsErr() {
+		return res
+	}
+	profile, err := getProfileFromAddress(store, callerAddr)
+	if err != nil {
+		return abciErrNoSender
+	}
+
+	schedule, err := getSchedule(store, tx.ID)
+	if err != nil {
+		return abciErrScheduleMissing
+	}
+	if schedule.Sender != profile.Name {
+		return abciErrNotOwner("schedule was opened by another profile")
+	}
+	if !schedule.Active {
+		return abciErrScheduleInactive
+	}
+
+	schedule.Active = false
+	store.Set(ScheduleKey(schedule.ID), wire.BinaryBytes(schedule))
+
+	schedules, err := getListBytes(store, ListScheduleKey())
+	if err != nil {
+		return abciErrInternal(err)
+	}
+	for i, v := range schedules {
+		if bytes.Compare(v, schedule.ID) == 0 {
+			schedules = append(schedules[:i], schedules[i+1:]...)
+			break
+		}
+	}
+	store.Set(ListScheduleKey(), wire.BinaryBytes(schedules))
+	return abci.OK
+}
+
+// issueSchedules issues the invoices of all active schedules which are due
+//   by the block time, finished schedules are removed from the schedule list
+func issueSchedules(store btypes.KVStore) error {
+	blockTime, err := getBlockTime(store)
+	if err != nil {
+		return err
+	}
+	ids, err := getListBytes(store, ListScheduleKey())
+	if err != nil {
+		return err
+	}
+
+	var active [][]byte
+	for _, id := range ids {
+		schedule, err := getSchedule(store, id)
+		if err != nil {
+			return err
+		}
+		changed, err := issueSchedule(store, &schedule, blockTime)
+		if err != nil {
+			return err
+		}
+		if changed {
+			store.Set(ScheduleKey(id), wire.BinaryBytes(schedule))
+		}
+		if schedule.Active {
+			active = append(active, id)
+		}
+	}
+	if len(active) != len(ids) {
+		store.Set(ListScheduleKey(), wire.BinaryBytes(active))
+	}
+	return nil
+}
+
+// maxScheduleIssues is the most invoices a schedule issues in a block,
+//   further occurrences which are due are deferred to the following blocks
+const maxScheduleIssues = 10
+
+// issueSchedule issues an invoice for each occurrence of the schedule up to
+//   the block time, at most maxScheduleIssues. An occurrence which cannot be
+//   issued, for instance if no exchange rate was posted, is skipped and the
+//   reason recorded in the schedule. Returns true if the schedule was modified.
+func issueSchedule(store btypes.KVStore, schedule *types.Schedule,
+	blockTime time.Time) (changed bool, err error) {
+
+	cadence, err := common.ParseCadence(schedule.Cadence)
+	if err != nil {
+		return false, err
+	}
+	for n := 0; n < maxScheduleIssues && !schedule.Finished() && !schedule.Next.After(blockTime); n++ {
+		id, res := issueScheduleInvoice(store, schedule)
+		if res.IsErr() {
+			schedule.LastError = res.Log
+		} else {
+			schedule.LastError = ""
+			schedule.InvoiceIDs = append(schedule.InvoiceIDs, id)
+		}
+		schedule.Issued++
+		schedule.Next = cadence.Next(schedule.Start, schedule.Next)
+		changed = true
+	}
+	if schedule.Active && schedule.Finished() {
+		schedule.Active = false
+		changed = true
+	}
+	return changed, nil
+}
+
+// issueScheduleInvoice issues the template invoice dated on the next occurrence
+func issueScheduleInvoice(store btypes.KVStore, schedule *types.Schedule) ([]byte, abci.Result) {
+	profile, err := getProfile(store, schedule.Sender)
+	if err != nil {
+		return nil, abciErrNoSender
+	}
+	if !profile.Active {
+		return nil, abciErrProfileInactive
+	}
+
+	template := *schedule.Template
+	template.Date = schedule.Next.UTC().Format(common.TimeLayout)
+	invoice, res := buildInvoice(store, TBTxContractOpen, &profile, &template)
+	if res.IsErr() {
+		return nil, res
+	}
+	invoice.GetCtx().ScheduleID = schedule.ID
+	res = runActionInvoice(store, invoice, false)
+	if res.IsErr() {
+		return nil, res
+	}
+	return invoice.GetID(), abci.OK
+}
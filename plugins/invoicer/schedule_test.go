@@ -0,0 +1,140 @@
+package invoicer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestSchedule(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := btypes.NewMemKVStore()
+	inv := New()
+	beginBlock := func(date time.Time) {
+		inv.BeginBlock(store, nil, &abci.Header{Time: uint64(date.Unix())})
+		inv.EndBlock(store, 0)
+	}
+	jan31 := time.Date(2017, time.Month(1), 31, 0, 0, 0, 0, time.UTC)
+	beginBlock(jan31)
+
+	sender, receiver := []byte("sender"), []byte("receiver")
+	for _, p := range []struct {
+		addr []byte
+		name string
+	}{{sender, "foo"}, {receiver, "bar"}} {
+		tx := types.TxProfile{Name: p.name, AcceptedCur: "USD", DueDurationDays: 14}
+		res := runTxProfile(store, p.addr, MarshalWithTB(tx, TBTxProfileOpen))
+		require.True(res.IsOK(), res.Log)
+	}
+
+	tx := types.TxScheduleOpen{
+		Invoice:     types.TxInvoice{Amount: "100USD", SenderAddr: sender, To: "bar"},
+		Cadence:     "monthly",
+		Start:       "2017-01-31",
+		Occurrences: 3,
+	}
+	res := runTxScheduleOpen(store, sender, MarshalWithTB(tx, TBTxScheduleOpen))
+	require.True(res.IsOK(), res.Log)
+	id := []byte(res.Data)
+
+	//duplicates and bad cadences are rejected
+	res = runTxScheduleOpen(store, sender, MarshalWithTB(tx, TBTxScheduleOpen))
+	assert.True(res.IsErr())
+	tx.Cadence = "hourly"
+	res = runTxScheduleOpen(store, sender, MarshalWithTB(tx, TBTxScheduleOpen))
+	assert.True(res.IsErr())
+
+	//invoices are issued by the end block for each occurrence due
+	issued := func() []types.Invoice {
+		schedule, err := getSchedule(store, id)
+		require.Nil(err)
+		var out []types.Invoice
+		for _, invID := range schedule.InvoiceIDs {
+			invoice, err := getInvoice(store, invID)
+			require.Nil(err)
+			assert.Equal(id, invoice.GetCtx().ScheduleID)
+			out = append(out, invoice)
+		}
+		return out
+	}
+	require.Len(issued(), 0)
+	beginBlock(jan31.Add(time.Hour))
+	invoices := issued()
+	require.Len(invoices, 1)
+	assert.Equal("foo", invoices[0].GetCtx().Sender)
+	assert.Equal("bar", invoices[0].GetCtx().Receiver)
+	assert.Equal("100", invoices[0].GetCtx().Invoiced.Amount)
+
+	//missed occurrences are caught up, clamped to the end of the month
+	beginBlock(time.Date(2017, time.Month(3), 31, 1, 0, 0, 0, time.UTC))
+	invoices = issued()
+	require.Len(invoices, 3)
+	assert.True(time.Date(2017, time.Month(2), 28, 0, 0, 0, 0, time.UTC).Equal(
+		invoices[1].GetCtx().Invoiced.CurTime.Date))
+
+	//the schedule finishes after the occurrences
+	schedule, err := getSchedule(store, id)
+	require.Nil(err)
+	assert.False(schedule.Active)
+	schedules, err := getListBytes(store, ListScheduleKey())
+	require.Nil(err)
+	assert.Len(schedules, 0)
+
+	//only the sender may cancel a schedule
+	tx.Cadence, tx.Occurrences, tx.Start = "weekly", 0, "2017-03-31"
+	res = runTxScheduleOpen(store, sender, MarshalWithTB(tx, TBTxScheduleOpen))
+	require.True(res.IsOK(), res.Log)
+	cancel := types.TxScheduleCancel{ID: res.Data, SenderAddr: receiver}
+	res = runTxScheduleCancel(store, receiver, MarshalWithTB(cancel, TBTxScheduleCancel))
+	assert.Equal(CodeTypeNotOwner, res.Code, res.Log)
+	cancel.SenderAddr = sender
+	res = runTxScheduleCancel(store, sender, MarshalWithTB(cancel, TBTxScheduleCancel))
+	require.True(res.IsOK(), res.Log)
+	res = runTxScheduleCancel(store, sender, MarshalWithTB(cancel, TBTxScheduleCancel))
+	assert.True(res.IsErr())
+}
+
+func TestScheduleCatchUp(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openFooBar("foo")
+
+	open := func(start string) abci.Result {
+		tx := types.TxScheduleOpen{
+			Invoice: types.TxInvoice{Amount: "100USD", SenderAddr: f.sender, To: "bar"},
+			Cadence: "daily",
+			Start:   start,
+		}
+		return runTxScheduleOpen(f.store, f.sender, MarshalWithTB(tx, TBTxScheduleOpen))
+	}
+
+	//schedules cannot start before the current block
+	assert.True(open("0001-01-01").IsErr())
+	assert.True(open("2017-01-30").IsErr())
+	res := open("2017-01-31")
+	require.True(res.IsOK(), res.Log)
+	id := []byte(res.Data)
+	issued := func() int {
+		schedule, err := getSchedule(f.store, id)
+		require.Nil(err)
+		return len(schedule.InvoiceIDs)
+	}
+
+	//occurrences missed over a long gap are issued a few at a time
+	f.beginBlock(f.date.AddDate(1, 0, 0))
+	f.endBlock()
+	assert.Equal(maxScheduleIssues, issued())
+	f.beginBlock(f.date.Add(time.Second))
+	f.endBlock()
+	assert.Equal(2*maxScheduleIssues, issued())
+}
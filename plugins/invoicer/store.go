@@ -1,13 +1,14 @@
 package invoicer
 
 import (
-	"bytes"
 	"errors"
+	"time"
 
 	btypes "github.com/tendermint/basecoin/types"
 	"github.com/tendermint/go-wire"
 	cmn "github.com/tendermint/tmlibs/common"
 
+	"github.com/tendermint/trackomatron/common"
 	"github.com/tendermint/trackomatron/types"
 )
 
@@ -24,6 +25,34 @@ const (
 	TBTxExpenseEdit
 
 	TBTxPayment
+
+	TBTxRate
+
+	TBTxScheduleOpen
+	TBTxScheduleCancel
+
+	TBTxContractVoid
+	TBTxExpenseVoid
+
+	TBTxCreditNote
+
+	TBTxPaymentReverse
+	TBTxRefund
+
+	TBTxInvoiceApprove
+	TBTxInvoiceReject
+
+	TBTxInvoiceDispute
+	TBTxInvoiceRespond
+
+	TBTxOrgMemberSet
+	TBTxOrgMemberRemove
+	TBTxOrgChain
+
+	TBTxActionSign
+
+	TBTxProfileReactivate
+	TBTxProfileRotateKey
 )
 
 // MarshalWithTB marshals the object and then prepends a typebyte
@@ -37,6 +66,12 @@ func ProfileKey(name string) []byte {
 	return []byte(cmn.Fmt("%v,Profile=%v", Name, name))
 }
 
+// AddressKey generates a store key indexing the name of the profile
+//   controlled by an address
+func AddressKey(address []byte) []byte {
+	return []byte(cmn.Fmt("%v,Address=%x", Name, address))
+}
+
 // InvoiceKey generates a store key based on invoice id bytes
 func InvoiceKey(id []byte) []byte {
 	return []byte(cmn.Fmt("%v,ID=%x", Name, id))
@@ -47,6 +82,51 @@ func PaymentKey(transactionID string) []byte {
 	return []byte(cmn.Fmt("%v,Payment=%v", Name, transactionID))
 }
 
+// ReversalKey generates a store key based on the transaction id string
+//   of the payment reversed
+func ReversalKey(transactionID string) []byte {
+	return []byte(cmn.Fmt("%v,Reversal=%v", Name, transactionID))
+}
+
+// BalanceKey generates a store key for the credit balance held by the
+//   receiver from overpayments by the sender
+func BalanceKey(sender, receiver string) []byte {
+	return []byte(cmn.Fmt("%v,Balance=%v,%v", Name, sender, receiver))
+}
+
+// ScheduleKey generates a store key based on schedule id bytes
+func ScheduleKey(id []byte) []byte {
+	return []byte(cmn.Fmt("%v,Schedule=%x", Name, id))
+}
+
+// PendingActionKey generates a store key for the pending action of a
+//   multi-signature profile based on the action id bytes
+func PendingActionKey(id []byte) []byte {
+	return []byte(cmn.Fmt("%v,PendingAction=%x", Name, id))
+}
+
+// RateKey generates a store key based on the currency pair and date
+func RateKey(from, to string, date time.Time) []byte {
+	return []byte(cmn.Fmt("%v,Rate=%v/%v,Date=%v", Name, from, to, date.Format(common.TimeLayout)))
+}
+
+// ListRateKey generates the store key for the list of currency pairs
+//   with rates posted for a date
+func ListRateKey(date time.Time) []byte {
+	return []byte(cmn.Fmt("%v,Rates,Date=%v", Name, date.Format(common.TimeLayout)))
+}
+
+// ListOracleKey generates the store key for the list of addresses
+//   authorized to post exchange rates
+func ListOracleKey() []byte {
+	return []byte(cmn.Fmt("%v,Oracles", Name))
+}
+
+// BlockTimeKey generates the store key for the time of the current block
+func BlockTimeKey() []byte {
+	return []byte(cmn.Fmt("%v,BlockTime", Name))
+}
+
 // ListProfileActiveKey generates the store key for the list of active profiles
 func ListProfileActiveKey() []byte {
 	return []byte(cmn.Fmt("%v,Profiles", Name))
@@ -62,6 +142,29 @@ func ListInvoiceKey() []byte {
 	return []byte(cmn.Fmt("%v,Invoices", Name))
 }
 
+// ListAccrualKey generates the store key for the list of open invoices
+//   with a late fee policy
+func ListAccrualKey() []byte {
+	return []byte(cmn.Fmt("%v,Accruals", Name))
+}
+
+// ListCreditKey generates the store key for the list of open credit notes
+//   from the sender to the receiver
+func ListCreditKey(sender, receiver string) []byte {
+	return []byte(cmn.Fmt("%v,Credits=%v,%v", Name, sender, receiver))
+}
+
+// ListScheduleKey generates the store key for the list of active schedules
+func ListScheduleKey() []byte {
+	return []byte(cmn.Fmt("%v,Schedules", Name))
+}
+
+// ListPendingActionKey generates the store key for the list of actions
+//   awaiting signatures
+func ListPendingActionKey() []byte {
+	return []byte(cmn.Fmt("%v,PendingActions", Name))
+}
+
 // ListPaymentKey generates the store key for the list of invoice payments
 func ListPaymentKey() []byte {
 	return []byte(cmn.Fmt("%v,Payments", Name))
@@ -97,6 +200,77 @@ func GetPaymentFromWire(bytes []byte) (payment types.Payment, err error) {
 	return payment, wrapErrDecodingState(err)
 }
 
+// GetReversalFromWire payment reversal from marshalled bytes
+func GetReversalFromWire(bytes []byte) (reversal types.Reversal, err error) {
+	if len(bytes) == 0 {
+		return reversal, errStateNotFound
+	}
+
+	err = wire.ReadBinaryBytes(bytes, &reversal)
+	return reversal, wrapErrDecodingState(err)
+}
+
+// GetBalanceFromWire credit balance from marshalled bytes
+func GetBalanceFromWire(bytes []byte) (balance types.Balance, err error) {
+	if len(bytes) == 0 {
+		return balance, errStateNotFound
+	}
+
+	err = wire.ReadBinaryBytes(bytes, &balance)
+	return balance, wrapErrDecodingState(err)
+}
+
+// GetScheduleFromWire schedule from marshalled bytes
+func GetScheduleFromWire(bytes []byte) (schedule types.Schedule, err error) {
+	if len(bytes) == 0 {
+		return schedule, errStateNotFound
+	}
+
+	err = wire.ReadBinaryBytes(bytes, &schedule)
+	return schedule, wrapErrDecodingState(err)
+}
+
+// GetPendingActionFromWire pending action from marshalled bytes
+func GetPendingActionFromWire(bytes []byte) (action types.PendingAction, err error) {
+	if len(bytes) == 0 {
+		return action, errStateNotFound
+	}
+
+	err = wire.ReadBinaryBytes(bytes, &action)
+	return action, wrapErrDecodingState(err)
+}
+
+// GetNameFromWire profile name indexed by an address from marshalled bytes
+func GetNameFromWire(bytes []byte) (name string, err error) {
+	if len(bytes) == 0 {
+		return name, errStateNotFound
+	}
+
+	err = wire.ReadBinaryBytes(bytes, &name)
+	return name, wrapErrDecodingState(err)
+}
+
+// GetRateFromWire exchange rate from marshalled bytes
+func GetRateFromWire(bytes []byte) (rate types.ExchangeRate, err error) {
+	if len(bytes) == 0 {
+		return rate, errStateNotFound
+	}
+
+	err = wire.ReadBinaryBytes(bytes, &rate)
+	return rate, wrapErrDecodingState(err)
+}
+
+// GetBlockTimeFromWire block time from marshalled bytes,
+//   the zero time is returned if no block has begun
+func GetBlockTimeFromWire(bytes []byte) (blockTime time.Time, err error) {
+	if len(bytes) == 0 {
+		return blockTime, nil
+	}
+
+	err = wire.ReadBinaryBytes(bytes, &blockTime)
+	return blockTime, wrapErrDecodingState(err)
+}
+
 // GetListStringFromWire string array from marshalled bytes,
 //   currently used from profile and payment lists
 func GetListStringFromWire(bytes []byte) (out []string, err error) {
@@ -128,16 +302,55 @@ func getProfile(store btypes.KVStore, name string) (types.Profile, error) {
 	return GetProfileFromWire(bytes)
 }
 
+func getNameFromAddress(store btypes.KVStore, address []byte) (string, error) {
+	bytes := store.Get(AddressKey(address))
+	return GetNameFromWire(bytes)
+}
+
 func getInvoice(store btypes.KVStore, ID []byte) (types.Invoice, error) {
 	bytes := store.Get(InvoiceKey(ID))
 	return GetInvoiceFromWire(bytes)
 }
 
+func getPendingAction(store btypes.KVStore, ID []byte) (types.PendingAction, error) {
+	bytes := store.Get(PendingActionKey(ID))
+	return GetPendingActionFromWire(bytes)
+}
+
 func getPayment(store btypes.KVStore, transactionID []byte) (types.Payment, error) {
-	bytes := store.Get(InvoiceKey(transactionID))
+	bytes := store.Get(PaymentKey(string(transactionID)))
 	return GetPaymentFromWire(bytes)
 }
 
+func getReversal(store btypes.KVStore, transactionID string) (types.Reversal, error) {
+	bytes := store.Get(ReversalKey(transactionID))
+	return GetReversalFromWire(bytes)
+}
+
+func getBalance(store btypes.KVStore, sender, receiver string) (types.Balance, error) {
+	bytes := store.Get(BalanceKey(sender, receiver))
+	return GetBalanceFromWire(bytes)
+}
+
+func getSchedule(store btypes.KVStore, ID []byte) (types.Schedule, error) {
+	bytes := store.Get(ScheduleKey(ID))
+	return GetScheduleFromWire(bytes)
+}
+
+func getRate(store btypes.KVStore, from, to string, date time.Time) (types.ExchangeRate, error) {
+	bytes := store.Get(RateKey(from, to, date))
+	return GetRateFromWire(bytes)
+}
+
+func getBlockTime(store btypes.KVStore) (time.Time, error) {
+	bytes := store.Get(BlockTimeKey())
+	return GetBlockTimeFromWire(bytes)
+}
+
+func setBlockTime(store btypes.KVStore, blockTime time.Time) {
+	store.Set(BlockTimeKey(), wire.BinaryBytes(blockTime))
+}
+
 func getListString(store btypes.KVStore, key []byte) ([]string, error) {
 	bytes := store.Get(key)
 	return GetListStringFromWire(bytes)
@@ -152,24 +365,13 @@ func getListBytes(store btypes.KVStore, key []byte) ([][]byte, error) {
 
 func getProfileFromAddress(store btypes.KVStore, address []byte) (profile *types.Profile, err error) {
 
-	profiles, err := getListString(store, ListProfileActiveKey())
+	name, err := getNameFromAddress(store, address)
 	if err != nil {
-		return profile, err
-	}
-	found := false
-	for _, name := range profiles {
-		p, err := getProfile(store, name)
-		if err != nil {
-			return profile, err
-		}
-		if bytes.Compare(p.Address[:], address[:]) == 0 {
-			profile = &p
-			found = true
-			break
-		}
-	}
-	if !found {
 		return profile, errors.New("Could not retreive profile from address")
 	}
-	return profile, nil
+	p, err := getProfile(store, name)
+	if err != nil {
+		return profile, err
+	}
+	return &p, nil
 }
@@ -0,0 +1,99 @@
+package invoicer
+
+import (
+	abci "github.com/tendermint/abci/types"
+	btypes "github.com/tendermint/basecoin/types"
+
+	"github.com/tendermint/trackomatron/tax"
+	"github.com/tendermint/trackomatron/types"
+)
+
+// calculateInvoiceTax calculates the tax breakdown of a new invoice from the
+//   sender and receiver profiles, nil is returned if the sender is not
+//   registered for tax. Contracts are taxed on top of the amount whereas
+//   expenses have the tax included in the amount paid.
+func calculateInvoiceTax(store btypes.KVStore, tb byte, sender *types.Profile,
+	receiverName string, amt *types.AmtCurTime, lineItems []*types.LineItem) (*types.TaxBreakdown, abci.Result) {
+
+	if len(sender.TaxJurisdiction) == 0 {
+		return nil, abci.OK
+	}
+
+	switch tb {
+	case TBTxContractOpen, TBTxContractEdit:
+		receiver, err := getProfile(store, receiverName)
+		if err != nil {
+			return nil, abciErrNoReceiver
+		}
+		if len(lineItems) == 0 {
+			breakdown, err := tax.Calculate(sender, &receiver, amt)
+			if err != nil {
+				return nil, abciErrInternal(err)
+			}
+			return breakdown, abci.OK
+		}
+		breakdown, err := lineItemTax(sender, &receiver, lineItems)
+		if err != nil {
+			return nil, abciErrDecimal(err)
+		}
+		return breakdown, abci.OK
+
+	case TBTxExpenseOpen, TBTxExpenseEdit:
+		breakdown, err := tax.Included(sender.TaxJurisdiction, amt)
+		if err != nil {
+			return nil, abciErrInternal(err)
+		}
+		return breakdown, abci.OK
+	}
+	return nil, abciErrBadTypeByte
+}
+
+// lineItemTax calculates the tax of itemised contracts, the jurisdiction
+//   taxes the lines without their own tax rate and the tax of the other lines
+//   is added to the breakdown, the base is the total of the lines before tax
+func lineItemTax(sender, receiver *types.Profile,
+	lineItems []*types.LineItem) (*types.TaxBreakdown, error) {
+
+	base, err := types.SumLineSubtotals(lineItems)
+	if err != nil {
+		return nil, err
+	}
+	var own []*types.LineItem
+	taxable := base
+	for _, item := range lineItems {
+		if !item.HasTaxRate() {
+			continue
+		}
+		subtotal, err := item.Subtotal()
+		if err != nil {
+			return nil, err
+		}
+		taxable, err = taxable.Minus(subtotal)
+		if err != nil {
+			return nil, err
+		}
+		own = append(own, item)
+	}
+
+	breakdown, err := tax.Calculate(sender, receiver, taxable)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.Base = base
+	for _, item := range own {
+		amt, err := item.Tax()
+		if err != nil {
+			return nil, err
+		}
+		breakdown.Lines = append(breakdown.Lines, types.TaxLine{
+			Name:   item.Description,
+			Rate:   item.TaxRate,
+			Amount: amt,
+		})
+		breakdown.Total, err = breakdown.Total.Add(amt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return breakdown, nil
+}
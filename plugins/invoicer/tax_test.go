@@ -0,0 +1,43 @@
+package invoicer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+func TestLineItemTax(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f := newFixture(t)
+	f.openProfile(f.sender, types.TxProfile{Name: "foo", AcceptedCur: "EUR", DueDurationDays: 14,
+		TaxJurisdiction: "DE"})
+	f.openProfile(f.receiver, types.TxProfile{Name: "bar", AcceptedCur: "EUR", DueDurationDays: 14,
+		TaxJurisdiction: "DE"})
+
+	//the jurisdiction taxes the lines without their own rate
+	id := f.openInvoiceTx(types.TxInvoice{Notes: "mixed", LineItems: []types.TxLineItem{
+		{Description: "design", Quantity: "1", UnitPrice: "100EUR"},
+		{Description: "books", Quantity: "1", UnitPrice: "50EUR", TaxRate: "0.07"},
+	}})
+	ctx := f.ctx(id)
+	require.NotNil(ctx.Tax)
+	assert.Equal("150", ctx.Tax.Base.Amount)
+	assert.Equal("22.5", ctx.Tax.Total.Amount)
+	require.Len(ctx.Tax.Lines, 2)
+	assert.Equal("19", ctx.Tax.Lines[0].Amount.Amount)
+	assert.Equal("books", ctx.Tax.Lines[1].Name)
+	assert.Equal("3.5", ctx.Tax.Lines[1].Amount.Amount)
+	assert.Equal("172.5", ctx.Invoiced.Amount)
+
+	//lines which all set their own rate are not taxed by the jurisdiction
+	id = f.openInvoiceTx(types.TxInvoice{Notes: "own", LineItems: []types.TxLineItem{
+		{Description: "exempt", Quantity: "2", UnitPrice: "10EUR", TaxRate: "0"},
+	}})
+	assert.Equal("0", f.ctx(id).Tax.Total.Amount)
+	assert.Equal("20", f.ctx(id).Invoiced.Amount)
+}
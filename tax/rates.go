@@ -0,0 +1,100 @@
+package tax
+
+// Rate is a single tax levied within a jurisdiction
+type Rate struct {
+	Name string //Name of the tax eg. VAT, GST, PST
+	Rate string //Fraction of the taxable amount, eg. 0.2 for 20%
+}
+
+// Jurisdiction codes are ISO 3166-1 country codes, optionally followed by
+//   an ISO 3166-2 subdivision where tax varies within the country (eg. CA-ON)
+
+// destinationCountries levy tax at the rates of the receiver's subdivision
+//   rather than the sender's, a subdivision is required for these countries
+var destinationCountries = map[string]bool{
+	"CA": true,
+	"US": true,
+}
+
+// euCountries are VAT members for which cross border business to business
+//   supplies are reverse charged
+var euCountries = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "CY": true, "CZ": true, "DE": true,
+	"DK": true, "EE": true, "ES": true, "FI": true, "FR": true, "GR": true,
+	"HR": true, "HU": true, "IE": true, "IT": true, "LT": true, "LU": true,
+	"LV": true, "MT": true, "NL": true, "PL": true, "PT": true, "RO": true,
+	"SE": true, "SI": true, "SK": true,
+}
+
+// rateTable contains the standard rates of each supported jurisdiction
+var rateTable = map[string][]Rate{
+
+	//EU VAT
+	"AT": {{"VAT", "0.2"}},
+	"BE": {{"VAT", "0.21"}},
+	"BG": {{"VAT", "0.2"}},
+	"CY": {{"VAT", "0.19"}},
+	"CZ": {{"VAT", "0.21"}},
+	"DE": {{"VAT", "0.19"}},
+	"DK": {{"VAT", "0.25"}},
+	"EE": {{"VAT", "0.22"}},
+	"ES": {{"VAT", "0.21"}},
+	"FI": {{"VAT", "0.24"}},
+	"FR": {{"VAT", "0.2"}},
+	"GR": {{"VAT", "0.24"}},
+	"HR": {{"VAT", "0.25"}},
+	"HU": {{"VAT", "0.27"}},
+	"IE": {{"VAT", "0.23"}},
+	"IT": {{"VAT", "0.22"}},
+	"LT": {{"VAT", "0.21"}},
+	"LU": {{"VAT", "0.17"}},
+	"LV": {{"VAT", "0.21"}},
+	"MT": {{"VAT", "0.18"}},
+	"NL": {{"VAT", "0.21"}},
+	"PL": {{"VAT", "0.23"}},
+	"PT": {{"VAT", "0.23"}},
+	"RO": {{"VAT", "0.19"}},
+	"SE": {{"VAT", "0.25"}},
+	"SI": {{"VAT", "0.22"}},
+	"SK": {{"VAT", "0.2"}},
+
+	//Other VAT and GST
+	"GB": {{"VAT", "0.2"}},
+	"NO": {{"VAT", "0.25"}},
+	"AU": {{"GST", "0.1"}},
+	"NZ": {{"GST", "0.15"}},
+	"SG": {{"GST", "0.09"}},
+	"IN": {{"GST", "0.18"}},
+
+	//Canadian GST, HST and provincial sales taxes
+	"CA-AB": {{"GST", "0.05"}},
+	"CA-BC": {{"GST", "0.05"}, {"PST", "0.07"}},
+	"CA-MB": {{"GST", "0.05"}, {"RST", "0.07"}},
+	"CA-NB": {{"HST", "0.15"}},
+	"CA-NL": {{"HST", "0.15"}},
+	"CA-NS": {{"HST", "0.14"}},
+	"CA-NT": {{"GST", "0.05"}},
+	"CA-NU": {{"GST", "0.05"}},
+	"CA-ON": {{"HST", "0.13"}},
+	"CA-PE": {{"HST", "0.15"}},
+	"CA-QC": {{"GST", "0.05"}, {"QST", "0.09975"}},
+	"CA-SK": {{"GST", "0.05"}, {"PST", "0.06"}},
+	"CA-YT": {{"GST", "0.05"}},
+
+	//US state sales taxes, local sales taxes are not included
+	"US-AK": {},
+	"US-CA": {{"Sales Tax", "0.0725"}},
+	"US-CO": {{"Sales Tax", "0.029"}},
+	"US-DE": {},
+	"US-FL": {{"Sales Tax", "0.06"}},
+	"US-IL": {{"Sales Tax", "0.0625"}},
+	"US-MA": {{"Sales Tax", "0.0625"}},
+	"US-MT": {},
+	"US-NH": {},
+	"US-NJ": {{"Sales Tax", "0.06625"}},
+	"US-NY": {{"Sales Tax", "0.04"}},
+	"US-OR": {},
+	"US-PA": {{"Sales Tax", "0.06"}},
+	"US-TX": {{"Sales Tax", "0.0625"}},
+	"US-WA": {{"Sales Tax", "0.065"}},
+}
@@ -0,0 +1,104 @@
+package tax
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+//nolint Summary periods
+const (
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+	PeriodYear    = "year"
+)
+
+// Summary is the tax collected and paid by a profile within a period in one currency
+type Summary struct {
+	Period    string
+	Collected *types.AmtCurTime //Tax charged on invoices sent
+	Paid      *types.AmtCurTime //Tax charged on invoices received and included in expenses
+}
+
+// PeriodOf returns the name of the period which contains the date,
+//   eg. 2017-01 for a month, 2017-Q1 for a quarter or 2017 for a year
+func PeriodOf(date time.Time, period string) (string, error) {
+	switch period {
+	case PeriodMonth:
+		return date.Format("2006-01"), nil
+	case PeriodQuarter:
+		return fmt.Sprintf("%v-Q%v", date.Year(), (int(date.Month())-1)/3+1), nil
+	case PeriodYear:
+		return date.Format("2006"), nil
+	}
+	return "", errors.Errorf("unknown period %v, must be %v, %v or %v",
+		period, PeriodMonth, PeriodQuarter, PeriodYear)
+}
+
+// Summarize totals the tax collected and paid by the named profile for each
+//   period and currency of the invoices, sorted by period then currency.
+//   Reverse charged invoices are excluded as no tax was charged, as are
+//   voided invoices which were withdrawn.
+func Summarize(name string, invoices []types.Invoice, period string) ([]Summary, error) {
+
+	summaries := make(map[string]*Summary)
+	var keys []string
+	add := func(date time.Time, amt *types.AmtCurTime, collected bool) error {
+		p, err := PeriodOf(date, period)
+		if err != nil {
+			return err
+		}
+		key := p + "," + amt.CurTime.Cur
+		summary, ok := summaries[key]
+		if !ok {
+			zero, err := types.NewAmtCurTime(amt.CurTime.Cur, date, decimal.New(0, 0))
+			if err != nil {
+				return err
+			}
+			summary = &Summary{Period: p, Collected: zero, Paid: zero}
+			summaries[key] = summary
+			keys = append(keys, key)
+		}
+		if collected {
+			summary.Collected, err = summary.Collected.Add(amt)
+		} else {
+			summary.Paid, err = summary.Paid.Add(amt)
+		}
+		return err
+	}
+
+	for _, invoice := range invoices {
+		ctx := invoice.GetCtx()
+		date := ctx.Invoiced.CurTime.Date
+		expense, isExpense := invoice.Unwrap().(*types.Expense)
+
+		var err error
+		switch {
+		case ctx.Voided:
+			continue
+		case isExpense && ctx.Receiver == name && expense.ExpenseTaxes != nil:
+			err = add(date, expense.ExpenseTaxes, false)
+		case isExpense || ctx.Tax == nil || ctx.Tax.ReverseCharge:
+			continue
+		case ctx.Sender == name:
+			err = add(date, ctx.Tax.Total, true)
+		case ctx.Receiver == name:
+			err = add(date, ctx.Tax.Total, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(keys)
+	out := make([]Summary, len(keys))
+	for i, key := range keys {
+		out[i] = *summaries[key]
+	}
+	return out, nil
+}
@@ -0,0 +1,151 @@
+package tax
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+// ValidateJurisdiction checks that the jurisdiction has a rate table,
+//   the empty jurisdiction is valid and is never taxed
+func ValidateJurisdiction(jurisdiction string) error {
+	if len(jurisdiction) == 0 {
+		return nil
+	}
+	if _, ok := rateTable[jurisdiction]; ok {
+		return nil
+	}
+	if destinationCountries[jurisdiction] {
+		return errors.Errorf("tax jurisdiction %v requires a subdivision eg. %v-XX",
+			jurisdiction, jurisdiction)
+	}
+	return errors.Errorf("unknown tax jurisdiction %v", jurisdiction)
+}
+
+// Rates returns the rates levied in a jurisdiction
+func Rates(jurisdiction string) ([]Rate, error) {
+	if err := ValidateJurisdiction(jurisdiction); err != nil {
+		return nil, err
+	}
+	return rateTable[jurisdiction], nil
+}
+
+func country(jurisdiction string) string {
+	return strings.SplitN(jurisdiction, "-", 2)[0]
+}
+
+// Calculate determines the tax charged on top of the net amount of an invoice
+//   sent from the sender to the receiver profile, senders without a tax
+//   jurisdiction do not charge tax and are not calculated. The following rules apply:
+//    - supplies to another country are zero rated, except between EU members
+//      where the receiver has no tax ID and the sender's VAT applies
+//    - supplies between EU members to a receiver with a tax ID are reverse charged
+//    - supplies within CA or US are taxed at the receiver's subdivision rates,
+//      US sales tax is only charged within the sender's state
+//    - all other supplies are taxed at the sender's rates
+func Calculate(sender, receiver *types.Profile, net *types.AmtCurTime) (*types.TaxBreakdown, error) {
+
+	if err := ValidateJurisdiction(sender.TaxJurisdiction); err != nil {
+		return nil, err
+	}
+	if err := ValidateJurisdiction(receiver.TaxJurisdiction); err != nil {
+		return nil, err
+	}
+
+	senderCountry := country(sender.TaxJurisdiction)
+	receiverCountry := country(receiver.TaxJurisdiction)
+
+	var jurisdiction, note string
+	reverseCharge := false
+	switch {
+	case len(receiver.TaxJurisdiction) == 0:
+		jurisdiction = sender.TaxJurisdiction
+	case euCountries[senderCountry] && euCountries[receiverCountry] && senderCountry != receiverCountry:
+		if len(receiver.TaxID) > 0 {
+			reverseCharge = true
+			note = "intra-EU supply reverse charged to receiver " + receiver.TaxID
+		} else {
+			jurisdiction = sender.TaxJurisdiction
+		}
+	case senderCountry != receiverCountry:
+		note = "export to " + receiverCountry + " is zero rated"
+	case senderCountry == "US" && sender.TaxJurisdiction != receiver.TaxJurisdiction:
+		note = "no sales tax nexus in " + receiver.TaxJurisdiction
+	case destinationCountries[senderCountry]:
+		jurisdiction = receiver.TaxJurisdiction
+	default:
+		jurisdiction = sender.TaxJurisdiction
+	}
+
+	breakdown, err := calculate(jurisdiction, net, false)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.ReverseCharge = reverseCharge
+	breakdown.Note = note
+	return breakdown, nil
+}
+
+// Included determines the tax included in a gross amount paid within the
+//   jurisdiction, as used for expenses paid on behalf of the receiver
+func Included(jurisdiction string, gross *types.AmtCurTime) (*types.TaxBreakdown, error) {
+	if err := ValidateJurisdiction(jurisdiction); err != nil {
+		return nil, err
+	}
+	return calculate(jurisdiction, gross, true)
+}
+
+func calculate(jurisdiction string, base *types.AmtCurTime, inclusive bool) (*types.TaxBreakdown, error) {
+
+	amt, err := decimal.NewFromString(base.Amount)
+	if err != nil {
+		return nil, err
+	}
+	cur, date := base.CurTime.Cur, base.CurTime.Date
+
+	//for inclusive amounts remove the combined rate of all taxes first
+	divisor := decimal.New(1, 0)
+	rates := rateTable[jurisdiction]
+	if inclusive {
+		for _, rate := range rates {
+			r, err := decimal.NewFromString(rate.Rate)
+			if err != nil {
+				return nil, err
+			}
+			divisor = divisor.Add(r)
+		}
+	}
+
+	breakdown := &types.TaxBreakdown{
+		Base:      base,
+		Inclusive: inclusive,
+	}
+	breakdown.Total, err = types.NewAmtCurTime(cur, date, decimal.New(0, 0))
+	if err != nil {
+		return nil, err
+	}
+	for _, rate := range rates {
+		r, err := decimal.NewFromString(rate.Rate)
+		if err != nil {
+			return nil, err
+		}
+		taxAmt, err := types.NewAmtCurTime(cur, date, amt.Mul(r).Div(divisor))
+		if err != nil {
+			return nil, err
+		}
+		breakdown.Lines = append(breakdown.Lines, types.TaxLine{
+			Name:         rate.Name,
+			Jurisdiction: jurisdiction,
+			Rate:         rate.Rate,
+			Amount:       taxAmt,
+		})
+		breakdown.Total, err = breakdown.Total.Add(taxAmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,116 @@
+package tax
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/trackomatron/types"
+)
+
+var date = time.Date(2017, time.Month(2), 15, 0, 0, 0, 0, time.UTC)
+
+func TestCalculate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	net, err := types.ParseAmtCurTime("1000EUR", date)
+	require.Nil(err)
+
+	var testTax = []struct {
+		sender, receiver      string //jurisdictions
+		receiverTaxID         string
+		total                 string
+		lines                 int
+		reverseCharge, errNil bool
+	}{
+		{"DE", "DE", "", "190", 1, false, true},
+		{"DE", "", "", "190", 1, false, true},
+		{"DE", "FR", "FR123", "0", 0, true, true},
+		{"DE", "FR", "", "190", 1, false, true},
+		{"DE", "AU", "", "0", 0, false, true},
+		{"CA-ON", "CA-BC", "", "120", 2, false, true},
+		{"CA-BC", "CA-ON", "", "130", 1, false, true},
+		{"CA-QC", "CA-QC", "", "149.75", 2, false, true},
+		{"US-NY", "US-NY", "", "40", 1, false, true},
+		{"US-NY", "US-CA", "", "0", 0, false, true},
+		{"US", "US-CA", "", "", 0, false, false},
+		{"XX", "DE", "", "", 0, false, false},
+	}
+
+	for _, test := range testTax {
+		sender := &types.Profile{Name: "foo", TaxJurisdiction: test.sender}
+		receiver := &types.Profile{Name: "bar", TaxJurisdiction: test.receiver, TaxID: test.receiverTaxID}
+		breakdown, err := Calculate(sender, receiver, net)
+		if !test.errNil {
+			assert.NotNil(err, "%v to %v", test.sender, test.receiver)
+			continue
+		}
+		require.Nil(err, "%v to %v", test.sender, test.receiver)
+		assert.Equal(test.total, breakdown.Total.Amount, "%v to %v", test.sender, test.receiver)
+		assert.Len(breakdown.Lines, test.lines, "%v to %v", test.sender, test.receiver)
+		assert.Equal(test.reverseCharge, breakdown.ReverseCharge)
+		assert.False(breakdown.Inclusive)
+	}
+
+	//tax included in a gross amount
+	gross, err := types.ParseAmtCurTime("119EUR", date)
+	require.Nil(err)
+	breakdown, err := Included("DE", gross)
+	require.Nil(err)
+	assert.Equal("19", breakdown.Total.Amount)
+	assert.True(breakdown.Inclusive)
+}
+
+func TestSummarize(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	amt := func(amtCur string, date time.Time) *types.AmtCurTime {
+		out, err := types.ParseAmtCurTime(amtCur, date)
+		require.Nil(err)
+		return out
+	}
+	contract := func(sender, receiver string, date time.Time, tax string) types.Invoice {
+		invoice := types.NewContract(nil, sender, receiver, "", "", "EUR", date,
+			amt("1000EUR", date), amt("1000EUR", date)).Wrap()
+		invoice.GetCtx().Tax = &types.TaxBreakdown{Total: amt(tax, date)}
+		return invoice
+	}
+	march := date.AddDate(0, 1, 0)
+	april := date.AddDate(0, 2, 0)
+
+	expense := types.NewExpense(nil, "bar", "foo", "", "", "EUR", march,
+		amt("119EUR", march), amt("119EUR", march), nil, "", amt("19EUR", march)).Wrap()
+	reversed := contract("foo", "baz", march, "0EUR")
+	reversed.GetCtx().Tax.ReverseCharge = true
+	voided := contract("foo", "bar", march, "99EUR")
+	voided.GetCtx().Voided = true
+
+	invoices := []types.Invoice{
+		contract("foo", "bar", date, "190EUR"),
+		contract("foo", "bar", march, "10EUR"),
+		contract("bar", "foo", april, "20EUR"),
+		contract("foo", "bar", april, "5USD"),
+		contract("bar", "baz", april, "50EUR"),
+		expense,
+		reversed,
+		voided,
+	}
+
+	summaries, err := Summarize("foo", invoices, PeriodQuarter)
+	require.Nil(err)
+	require.Len(summaries, 3)
+	assert.Equal("2017-Q1", summaries[0].Period)
+	assert.Equal("200", summaries[0].Collected.Amount)
+	assert.Equal("19", summaries[0].Paid.Amount)
+	assert.Equal("2017-Q2", summaries[1].Period)
+	assert.Equal("EUR", summaries[1].Paid.CurTime.Cur)
+	assert.Equal("20", summaries[1].Paid.Amount)
+	assert.Equal("USD", summaries[2].Collected.CurTime.Cur)
+
+	_, err = Summarize("foo", invoices, "week")
+	assert.NotNil(err)
+}
@@ -6,8 +6,31 @@ CLIENT_EXE=trackocli
 ACCOUNTS=(jae ethan igor rigel)
 RICH=${ACCOUNTS[0]}
 
+#quickSetup from common.sh, registering the rich account as the exchange
+#rate oracle in the genesis before the server is started
 oneTimeSetUp() {
-    quickSetup .test_tracko tracko-chain
+    BASE_DIR=$HOME/.test_tracko
+    CHAIN_ID=tracko-chain
+    rm -rf $BASE_DIR 2>/dev/null
+    mkdir -p $BASE_DIR
+
+    export BC_HOME=${BASE_DIR}/client
+    prepareClient
+
+    SERVE_DIR=$BASE_DIR/server
+    SERVER_LOG=$BASE_DIR/${SERVER_EXE}.log
+    GENKEY=$(${CLIENT_EXE} keys get ${RICH} | awk '{print $2}')
+    ${SERVER_EXE} init --static --chain-id $CHAIN_ID $GENKEY --home=$SERVE_DIR >>$SERVER_LOG
+
+    ORACLE=$(${CLIENT_EXE} keys get ${RICH} --output=json | jq .address | tr -d '"')
+    GENESIS=$SERVE_DIR/genesis.json
+    jq --arg oracle "$ORACLE" '.app_options.plugin_options += ["invoicer/oracle", $oracle]' \
+        $GENESIS > $GENESIS.tmp && mv $GENESIS.tmp $GENESIS
+
+    startServer $SERVE_DIR $SERVER_LOG
+    if [ $? != 0 ]; then return 1; fi
+    initClient $CHAIN_ID
+    if [ $? != 0 ]; then return 1; fi
 }
 
 oneTimeTearDown() {
@@ -37,8 +60,9 @@ testOpeningProfiles(){
             seqUp 0
         fi 
 
-        #open the profile
-        TX=$(echo qwertyuiop | ${CLIENT_EXE} tx profile-open ${NAMES[$i]} --cur=BTC \
+        #open the profile, trusting the others so their invoices need no approval
+        TRUSTED=$(IFS=,; echo "${NAMES[*]}")
+        TX=$(echo qwertyuiop | ${CLIENT_EXE} tx profile-open ${NAMES[$i]} --cur=BTC --trusted=$TRUSTED \
             --amount=1mycoin --sequence=${SEQ[$i]} --name=${ACCOUNTS[$i]})
         txSucceeded $? "$TX" 
         seqUp $i
@@ -89,6 +113,28 @@ testEditingProfile(){
     assertNotEquals 'inactive profile should not be editable' "$CUR" "USD"
 }
 
+#Fixed exchange rates posted by the oracle, invoices are only converted
+#with the rates stored on chain for the invoice date
+BTC_USD=1000
+CAD_USD=0.75
+RATE_DATES=(2017-01-01 2017-01-02 2017-01-15 2017-02-01 2017-03-15)
+
+testPostingRates(){
+    for DATE in "${RATE_DATES[@]}"; do
+        TX=$(echo qwertyuiop | ${CLIENT_EXE} tx rate BTC USD $BTC_USD --date=$DATE \
+            --amount=1mycoin --sequence=${SEQ[0]} --name=${ACCOUNTS[0]})
+        txSucceeded $? "$TX"
+        seqUp 0
+    done
+    TX=$(echo qwertyuiop | ${CLIENT_EXE} tx rate CAD USD $CAD_USD --date=2017-01-01 \
+        --amount=1mycoin --sequence=${SEQ[0]} --name=${ACCOUNTS[0]})
+    txSucceeded $? "$TX"
+    seqUp 0
+
+    RATE=$(${CLIENT_EXE} query rate BTC USD --date=2017-01-01 | jq .Rate | tr -d '"')
+    assertEquals 'rate should be posted' "$BTC_USD" "$RATE"
+}
+
 testContractInvoice(){
     #Create the invoice
     TX=$(echo qwertyuiop | ${CLIENT_EXE} tx contract-open 1000.99USD --date=2017-01-01 --to=AllInBits --notes=thanks! \
@@ -116,7 +162,14 @@ testContractInvoice(){
     open=$(${CLIENT_EXE} query invoice 0x$ID | jq .data.Ctx.Open)
     assertEquals "Invoice should be open as not fully paid" "$open" "true"
 
-    TX=$(echo qwertyuiop | ${CLIENT_EXE} tx payment ${NAMES[1]} --ids=0x$ID --paid=0.2454003323983133BTC --date=2017-01-01 --tx-id=FOOBTC-TX-02 \
+    #the remainder of the 1000.99CAD invoice converted through USD at the posted rates,
+    #  BTC has 8 decimal places so the payable amount is exact
+    PAYABLE=$(echo "scale=8; 1000.99 * $CAD_USD / $BTC_USD" | bc)
+    REMAINING=$(echo "$PAYABLE - 0.5" | bc | sed 's/^\./0./')
+    PAYABLE2=$(${CLIENT_EXE} query invoice 0x$ID | jq .data.Ctx.Payable.Amount | tr -d '"')
+    assertEquals "payable $PAYABLE2 should be converted at the posted rates" 1 "$(echo "$PAYABLE == $PAYABLE2" | bc)"
+
+    TX=$(echo qwertyuiop | ${CLIENT_EXE} tx payment ${NAMES[1]} --ids=0x$ID --paid=${REMAINING}BTC --date=2017-01-01 --tx-id=FOOBTC-TX-02 \
         --amount=1mycoin --sequence=${SEQ[0]} --name=${ACCOUNTS[0]})
     txSucceeded $? "$TX" 
     seqUp 0 
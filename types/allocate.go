@@ -0,0 +1,94 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+//nolint Payment allocation strategies
+const (
+	AllocateOldest       = "oldest"       //invoices due soonest are paid first
+	AllocateNewest       = "newest"       //invoices due latest are paid first
+	AllocateProportional = "proportional" //split in proportion to the unpaid amount of each invoice
+	AllocateExplicit     = "explicit"     //amounts for each invoice are given with the payment
+)
+
+// InvoiceAllocation is the portion of a payment allocated to an invoice
+type InvoiceAllocation struct {
+	InvoiceID []byte
+	Amount    *AmtCurTime
+}
+
+// ParseAllocationStrategy parses a payment allocation strategy,
+//   the empty strategy is oldest-due-first
+func ParseAllocationStrategy(strategy string) (string, error) {
+	strategy = strings.ToLower(strings.TrimSpace(strategy))
+	switch strategy {
+	case "":
+		return AllocateOldest, nil
+	case AllocateOldest, AllocateNewest, AllocateProportional, AllocateExplicit:
+		return strategy, nil
+	}
+	return "", errors.Errorf("bad allocation strategy %v, must be %v, %v, %v or %v", strategy,
+		AllocateOldest, AllocateNewest, AllocateProportional, AllocateExplicit)
+}
+
+// SplitProportional splits the fund between the unpaid amounts in proportion
+//   to each. Shares are rounded down to the minor units of the currency and
+//   the remainder is given in order to the shares which are below their unpaid
+//   amount, so no share exceeds its unpaid amount when the fund does not
+//   exceed the total unpaid.
+func SplitProportional(fund *AmtCurTime, unpaid []*AmtCurTime) ([]*AmtCurTime, error) {
+	currency, err := GetCurrency(fund.CurTime.Cur)
+	if err != nil {
+		return nil, err
+	}
+	amt, err := decimal.NewFromString(fund.Amount)
+	if err != nil {
+		return nil, err
+	}
+	total := decimal.New(0, 0)
+	amts := make([]decimal.Decimal, len(unpaid))
+	for i, u := range unpaid {
+		if u.CurTime.Cur != fund.CurTime.Cur {
+			return nil, errors.Errorf("cannot split %v between amounts in %v", fund.CurTime.Cur, u.CurTime.Cur)
+		}
+		amts[i], err = decimal.NewFromString(u.Amount)
+		if err != nil {
+			return nil, err
+		}
+		total = total.Add(amts[i])
+	}
+	if total.Sign() <= 0 {
+		return nil, errors.New("cannot split a payment between invoices with nothing unpaid")
+	}
+
+	shares := make([]decimal.Decimal, len(unpaid))
+	rest := amt
+	for i := range amts {
+		shares[i] = amt.Mul(amts[i]).Div(total).Truncate(currency.MinorUnits)
+		rest = rest.Sub(shares[i])
+	}
+	for i := range shares {
+		if rest.Sign() <= 0 {
+			break
+		}
+		add := amts[i].Sub(shares[i])
+		if add.GreaterThan(rest) {
+			add = rest
+		}
+		shares[i] = shares[i].Add(add)
+		rest = rest.Sub(add)
+	}
+
+	out := make([]*AmtCurTime, len(shares))
+	for i, share := range shares {
+		out[i], err = NewAmtCurTime(fund.CurTime.Cur, fund.CurTime.Date, share)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
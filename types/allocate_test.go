@@ -0,0 +1,65 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitProportional(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var testSplits = []struct {
+		fund   string
+		unpaid []string
+		shares []string
+		errNil bool
+	}{
+		{"100USD", []string{"100USD", "200USD", "100USD"}, []string{"25", "50", "25"}, true},
+		{"0.10USD", []string{"100USD", "100USD", "100USD"}, []string{"0.04", "0.03", "0.03"}, true},
+		{"300USD", []string{"100USD", "200USD"}, []string{"100", "200"}, true},
+		{"10JPY", []string{"3JPY", "3JPY", "4JPY"}, []string{"3", "3", "4"}, true},
+		{"10USD", []string{"10EUR"}, nil, false},
+		{"10USD", []string{"0USD"}, nil, false},
+	}
+
+	for _, test := range testSplits {
+		fund, err := ParseAmtCurTime(test.fund, time.Time{})
+		require.Nil(err)
+		var unpaid []*AmtCurTime
+		for _, u := range test.unpaid {
+			amt, err := ParseAmtCurTime(u, time.Time{})
+			require.Nil(err)
+			unpaid = append(unpaid, amt)
+		}
+		shares, err := SplitProportional(fund, unpaid)
+		if !test.errNil {
+			assert.NotNil(err, test.fund)
+			continue
+		}
+		if assert.Nil(err, test.fund) {
+			var out []string
+			for _, share := range shares {
+				out = append(out, share.Amount)
+			}
+			assert.Equal(test.shares, out, test.fund+" "+strings.Join(test.unpaid, ","))
+		}
+	}
+}
+
+func TestParseAllocationStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	strategy, err := ParseAllocationStrategy("")
+	assert.Nil(err)
+	assert.Equal(AllocateOldest, strategy)
+	strategy, err = ParseAllocationStrategy("Proportional")
+	assert.Nil(err)
+	assert.Equal(AllocateProportional, strategy)
+	_, err = ParseAllocationStrategy("cheapest")
+	assert.NotNil(err)
+}
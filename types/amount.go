@@ -0,0 +1,195 @@
+package types
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// currencySymbols maps unambiguous currency symbols to their codes
+var currencySymbols = map[rune]string{
+	'$': "USD",
+	'€': "EUR",
+	'£': "GBP",
+	'₿': "BTC",
+	'Ξ': "ETH",
+}
+
+// ambiguousSymbols are symbols shared between multiple currencies
+var ambiguousSymbols = map[rune]string{
+	'¥': "JPY or CNY",
+	'¢': "a cent of any currency",
+}
+
+// parseAmount parses an amount of the grammar:
+//   amount := [sign] [prefix] number [suffix]
+//   prefix := symbol | code whitespace
+//   suffix := [whitespace] code
+//   number := digits with an optional decimal separator and thousands grouping
+// where exactly one of prefix or suffix must name the currency (or both
+// naming the same currency). Codes are case-insensitive and returned in
+// upper case. Negative amounts are rejected.
+func parseAmount(amtCur string) (cur string, amt decimal.Decimal, err error) {
+
+	in := []rune(strings.TrimSpace(amtCur))
+	if len(in) == 0 {
+		return cur, amt, errors.New("empty amount, must be in the format <decimal><currency>")
+	}
+	pos := 0
+	skipSpace := func() {
+		for pos < len(in) && unicode.IsSpace(in[pos]) {
+			pos++
+		}
+	}
+	readWhile := func(cond func(rune) bool) string {
+		start := pos
+		for pos < len(in) && cond(in[pos]) {
+			pos++
+		}
+		return string(in[start:pos])
+	}
+	isNumber := func(r rune) bool { return ('0' <= r && r <= '9') || r == '.' || r == ',' }
+
+	//sign
+	negative := false
+	if in[pos] == '-' || in[pos] == '+' {
+		negative = in[pos] == '-'
+		pos++
+		skipSpace()
+	}
+
+	//prefix, either a symbol or a code followed by whitespace
+	var prefix string
+	if pos < len(in) {
+		r := in[pos]
+		if code, ok := currencySymbols[r]; ok {
+			prefix = code
+			pos++
+			skipSpace()
+		} else if which, ok := ambiguousSymbols[r]; ok {
+			return cur, amt, errors.Errorf("ambiguous currency symbol %c (%v) in %v, use the currency code", r, which, amtCur)
+		} else if unicode.IsLetter(r) {
+			prefix = strings.ToUpper(readWhile(unicode.IsLetter))
+			switch {
+			case pos == len(in):
+				return cur, amt, errors.Errorf("missing amount in %v", amtCur)
+			case !unicode.IsSpace(in[pos]):
+				return cur, amt, errors.Errorf("currency code prefix %v must be separated from the amount by a space in %v",
+					prefix, amtCur)
+			}
+			skipSpace()
+		}
+	}
+
+	//number
+	number := readWhile(isNumber)
+	if len(number) == 0 {
+		if pos < len(in) {
+			return cur, amt, errors.Errorf("unexpected character %q at position %v in %v", in[pos], pos+1, amtCur)
+		}
+		return cur, amt, errors.Errorf("missing amount in %v", amtCur)
+	}
+
+	//suffix code
+	skipSpace()
+	suffix := strings.ToUpper(readWhile(unicode.IsLetter))
+	if pos < len(in) {
+		return cur, amt, errors.Errorf("unexpected character %q at position %v in %v", in[pos], pos+1, amtCur)
+	}
+
+	switch {
+	case len(prefix) == 0 && len(suffix) == 0:
+		return cur, amt, errors.Errorf("missing currency in %v", amtCur)
+	case len(prefix) > 0 && len(suffix) > 0 && prefix != suffix:
+		return cur, amt, errors.Errorf("conflicting currencies %v and %v in %v", prefix, suffix, amtCur)
+	case len(suffix) > 0:
+		cur = suffix
+	default:
+		cur = prefix
+	}
+	currency, err := GetCurrency(cur)
+	if err != nil {
+		return cur, amt, err
+	}
+
+	amt, err = parseNumber(number)
+	if err != nil {
+		return cur, amt, errors.WithMessage(err, "bad amount "+amtCur)
+	}
+	if negative {
+		return cur, amt, errors.Errorf("negative amounts are not allowed, %v", amtCur)
+	}
+	return cur, amt, currency.Validate(amt)
+}
+
+// parseNumber parses an unsigned decimal number which may use either
+//   '.' or ',' as the decimal separator and the other for thousands grouping.
+//   Numbers where the role of a separator is unclear are rejected, a lone
+//   separator followed by three digits could group thousands unless the
+//   digits before it are zero or too many for a group.
+func parseNumber(number string) (amt decimal.Decimal, err error) {
+
+	dots, commas := strings.Count(number, "."), strings.Count(number, ",")
+	lastDot, lastComma := strings.LastIndex(number, "."), strings.LastIndex(number, ",")
+
+	var decimalSep, groupSep string
+	switch {
+	case dots == 0 && commas == 0:
+	case dots > 0 && commas > 0:
+		//the last separator is the decimal, the other groups
+		decimalSep, groupSep = ".", ","
+		if lastComma > lastDot {
+			decimalSep, groupSep = ",", "."
+		}
+		if strings.Count(number, decimalSep) > 1 {
+			return amt, errors.Errorf("decimal separator %v used more than once", decimalSep)
+		}
+	case dots > 1:
+		groupSep = "."
+	case commas > 1:
+		groupSep = ","
+	default:
+		//a single separator with three digits following could be either
+		last := lastDot
+		decimalSep = "."
+		if commas == 1 {
+			decimalSep, last = ",", lastComma
+		}
+		intPart := number[:last]
+		if len(number)-last-1 == 3 && len(intPart) > 0 && len(intPart) <= 3 && intPart[0] != '0' {
+			return amt, errors.Errorf("ambiguous separator in %v, use %v or %v",
+				number, strings.Replace(number, decimalSep, "", 1), number+"0")
+		}
+	}
+
+	intPart, fracPart := number, ""
+	if len(decimalSep) > 0 {
+		parts := strings.SplitN(number, decimalSep, 2)
+		intPart, fracPart = parts[0], parts[1]
+		if len(fracPart) == 0 {
+			return amt, errors.New("missing digits after the decimal separator")
+		}
+		if strings.ContainsAny(fracPart, ".,") {
+			return amt, errors.New("separator after the decimal separator")
+		}
+	}
+	if len(intPart) == 0 {
+		return amt, errors.New("missing digits before the decimal separator")
+	}
+	if len(groupSep) > 0 {
+		groups := strings.Split(intPart, groupSep)
+		for i, group := range groups {
+			if (i == 0 && (len(group) == 0 || len(group) > 3)) || (i > 0 && len(group) != 3) {
+				return amt, errors.Errorf("invalid digit grouping in %v", number)
+			}
+		}
+		intPart = strings.Join(groups, "")
+	}
+
+	if len(fracPart) > 0 {
+		return decimal.NewFromString(intPart + "." + fracPart)
+	}
+	return decimal.NewFromString(intPart)
+}
@@ -1,7 +1,6 @@
 package types
 
 import (
-	"regexp"
 	"time"
 
 	"github.com/pkg/errors"
@@ -20,19 +19,58 @@ type AmtCurTime struct {
 	Amount  string //Decimal Number
 }
 
-// ParseAmtCurTime parse AmtCurTime from <amt><cur> and date
-func ParseAmtCurTime(amtCur string, date time.Time) (*AmtCurTime, error) {
+// ExchangeRate represents the value of one unit of the From denom
+//   expressed in the To denom on a given date
+type ExchangeRate struct {
+	From string
+	To   string
+	Date time.Time
+	Rate string //Decimal Number
+}
+
+// Conversion records the exchange rates used to convert between two
+//   denoms, Path lists the denoms traversed from the input to the output
+//   and Rates lists the rates, as posted, used for each step of the path
+type Conversion struct {
+	Path  []string
+	Rates []ExchangeRate
+}
 
-	if len(amtCur) == 0 {
-		return nil, errors.New("not enought information to parse AmtCurTime")
+// ParseAmtCurTime parse AmtCurTime from <amt><cur> and date, the amount
+//   must be non-negative, see parseAmount for the full grammar
+func ParseAmtCurTime(amtCur string, date time.Time) (*AmtCurTime, error) {
+	cur, amt, err := parseAmount(amtCur)
+	if err != nil {
+		return nil, err
 	}
+	return &AmtCurTime{CurrencyTime{cur, date}, amt.String()}, nil
+}
 
-	var reAmt = regexp.MustCompile("([\\d\\.]+)")
-	var reCur = regexp.MustCompile("([^\\d\\W]+)")
-	amt := reAmt.FindString(amtCur)
-	cur := reCur.FindString(amtCur)
+// NewAmtCurTime creates an AmtCurTime rounding the amount to
+//   the minor units of the currency
+func NewAmtCurTime(cur string, date time.Time, amt decimal.Decimal) (*AmtCurTime, error) {
+	currency, err := GetCurrency(cur)
+	if err != nil {
+		return nil, err
+	}
+	return &AmtCurTime{CurrencyTime{cur, date}, currency.Round(amt).String()}, nil
+}
 
-	return &AmtCurTime{CurrencyTime{cur, date}, amt}, nil
+// Validate checks that the currency is registered and that the amount is
+//   a decimal number within the precision of the currency
+func (a *AmtCurTime) Validate() error {
+	if a == nil {
+		return errors.New("amount is nil")
+	}
+	currency, err := GetCurrency(a.CurTime.Cur)
+	if err != nil {
+		return err
+	}
+	amt, err := decimal.NewFromString(a.Amount)
+	if err != nil {
+		return errors.Errorf("bad amount %v%v", a.Amount, a.CurTime.Cur)
+	}
+	return currency.Validate(amt)
 }
 
 //AmtCurTime Algebra
@@ -49,7 +87,7 @@ func (a *AmtCurTime) Add(a2 *AmtCurTime) (*AmtCurTime, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &AmtCurTime{CurrencyTime{a.CurTime.Cur, a.CurTime.Date}, amt1.Add(amt2).String()}, nil
+		return NewAmtCurTime(a.CurTime.Cur, a.CurTime.Date, amt1.Add(amt2))
 	case a == nil && a2 == nil:
 		return nil, nil
 	}
@@ -68,7 +106,7 @@ func (a *AmtCurTime) Minus(a2 *AmtCurTime) (*AmtCurTime, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &AmtCurTime{CurrencyTime{a.CurTime.Cur, a.CurTime.Date}, amt1.Sub(amt2).String()}, nil
+		return NewAmtCurTime(a.CurTime.Cur, a.CurTime.Date, amt1.Sub(amt2))
 	case a == nil && a2 == nil:
 		return nil, errors.New("a is nil")
 	}
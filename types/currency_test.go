@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -13,7 +14,7 @@ var (
 	date2 = time.Date(2016, time.Month(12), 31, 0, 0, 0, 0, time.UTC)
 )
 
-func testParse(t *testing.T) {
+func TestParse(t *testing.T) {
 	assert := assert.New(t)
 
 	parse, err := ParseAmtCurTime("100BTC", date)
@@ -28,6 +29,54 @@ func testParse(t *testing.T) {
 	assert.NotNil(err)
 	_, err = ParseAmtCurTime("", date)
 	assert.NotNil(err)
+
+	var testAmounts = []struct {
+		in     string
+		cur    string
+		amt    string
+		errNil bool
+	}{
+		{"10.23usd", "USD", "10.23", true},
+		{"10.23 USD", "USD", "10.23", true},
+		{"USD 10.23", "USD", "10.23", true},
+		{"$10.23", "USD", "10.23", true},
+		{"$ 10.23 usd", "USD", "10.23", true},
+		{"€1.000,50", "EUR", "1000.5", true},
+		{"1,000.50EUR", "EUR", "1000.5", true},
+		{"1.000.000JPY", "JPY", "1000000", true},
+		{"1,000,000JPY", "JPY", "1000000", true},
+		{"10,5EUR", "EUR", "10.5", true},
+		{"0.005BTC", "BTC", "0.005", true},
+		{"1234.500BTC", "BTC", "1234.5", true},
+		{"1.0005BTC", "BTC", "1.0005", true},
+		{"0,005BTC", "BTC", "0.005", true},
+		{"+5USD", "USD", "5", true},
+		{"1,000USD", "", "", false},    //ambiguous
+		{"€1.000", "", "", false},      //ambiguous
+		{"1.000BTC", "", "", false},    //ambiguous
+		{"1.2.3USD", "", "", false},    //bad grouping
+		{"1,00,000USD", "", "", false}, //bad grouping
+		{"1.000,000,5EUR", "", "", false},
+		{"1.USD", "", "", false},
+		{".5USD", "", "", false},
+		{"$10EUR", "", "", false}, //conflicting
+		{"¥100", "", "", false},   //ambiguous symbol
+		{"100 USD x", "", "", false},
+		{"10#USD", "", "", false},
+		{"-5USD", "", "", false}, //unsigned
+		{"100FOO", "", "", false},
+	}
+	for _, test := range testAmounts {
+		a, err := ParseAmtCurTime(test.in, date)
+		if !test.errNil {
+			assert.NotNil(err, test.in)
+			continue
+		}
+		if assert.Nil(err, test.in) {
+			assert.Equal(test.cur, a.CurTime.Cur, test.in)
+			assert.Equal(test.amt, a.Amount, test.in)
+		}
+	}
 }
 
 func testEqualities(t *testing.T) {
@@ -141,3 +190,56 @@ func testAddMinus(t *testing.T) {
 	assert.True(eq)
 
 }
+
+func TestCurrencyRound(t *testing.T) {
+	assert := assert.New(t)
+
+	var testRound = []struct {
+		currency Currency
+		in, out  string
+	}{
+		{Currency{"USD", 2, RoundHalfUp}, "1.005", "1.01"},
+		{Currency{"USD", 2, RoundHalfUp}, "-1.005", "-1.01"},
+		{Currency{"USD", 2, RoundHalfEven}, "1.005", "1"},
+		{Currency{"USD", 2, RoundHalfEven}, "1.015", "1.02"},
+		{Currency{"USD", 2, RoundHalfEven}, "1.0151", "1.02"},
+		{Currency{"USD", 2, RoundHalfEven}, "-1.025", "-1.02"},
+		{Currency{"JPY", 0, RoundHalfEven}, "2.5", "2"},
+		{Currency{"BTC", 8, RoundDown}, "0.2454003323983133", "0.24540033"},
+		{Currency{"BTC", 8, RoundDown}, "-0.2454003399", "-0.24540033"},
+	}
+
+	for _, test := range testRound {
+		in, err := decimal.NewFromString(test.in)
+		assert.Nil(err)
+		assert.Equal(test.out, test.currency.Round(in).String(), test.in)
+	}
+}
+
+func TestAmountPrecision(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, err := ParseAmtCurTime("0.24540033BTC", date)
+	assert.Nil(err)
+	_, err = ParseAmtCurTime("0.2454003323983133BTC", date)
+	assert.NotNil(err)
+	_, err = ParseAmtCurTime("10.001USD", date)
+	assert.NotNil(err)
+	_, err = ParseAmtCurTime("100JPY", date)
+	assert.Nil(err)
+	_, err = ParseAmtCurTime("100XYZ", date)
+	assert.NotNil(err)
+
+	//arithmetic results are rounded to the currency
+	a, err := NewAmtCurTime("USD", date, decimal.New(10125, -3))
+	require.Nil(err)
+	assert.Equal("10.12", a.Amount)
+	b := &AmtCurTime{CurrencyTime{"USD", date}, "0.005"}
+	sum, err := a.Add(b)
+	require.Nil(err)
+	assert.Equal("10.12", sum.Amount)
+	diff, err := a.Minus(b)
+	require.Nil(err)
+	assert.Equal("10.12", diff.Amount)
+}
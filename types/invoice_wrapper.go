@@ -60,3 +60,11 @@ func init() {
 func (hi *Expense) Wrap() Invoice {
 	return Invoice{hi}
 }
+
+func init() {
+	InvoiceMapper.RegisterImplementation(&CreditNote{}, "credit-note", 0x3)
+}
+
+func (hi *CreditNote) Wrap() Invoice {
+	return Invoice{hi}
+}
@@ -0,0 +1,269 @@
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+//nolint Interest periods
+const (
+	InterestDaily   = "day"
+	InterestMonthly = "month"
+)
+
+// LateFeePolicy are the charges accrued onto an invoice once it is overdue
+type LateFeePolicy struct {
+	FlatFee        *AmtCurTime //Fee charged once when the invoice becomes overdue
+	InterestRate   string      //Fraction of the unpaid principal charged each period
+	InterestPeriod string      //Period interest is charged per, day or month
+	Cap            string      //Maximum total late charges as a fraction of the payable amount, 0 for no cap
+}
+
+// ParseLateFeePolicy parses a late fee policy of comma separated clauses:
+//   fee <amount>                 flat fee charged once when overdue, eg. fee 25USD
+//   interest <percent>/<period>  interest per day or month, eg. interest 1.5%/month
+//   cap <percent>                maximum charges of the payable amount, eg. cap 10%
+func ParseLateFeePolicy(policy string) (*LateFeePolicy, error) {
+	out := &LateFeePolicy{InterestRate: "0", Cap: "0"}
+	for _, clause := range strings.Split(policy, ",") {
+		fields := strings.Fields(clause)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("bad late fee clause %v, must be one of "+
+				"fee <amount>, interest <percent>/<day|month> or cap <percent>", strings.TrimSpace(clause))
+		}
+		var err error
+		switch strings.ToLower(fields[0]) {
+		case "fee":
+			out.FlatFee, err = ParseAmtCurTime(fields[1], time.Time{})
+		case "interest":
+			parts := strings.SplitN(fields[1], "/", 2)
+			if len(parts) != 2 {
+				return nil, errors.Errorf("bad late fee interest %v, must be <percent>/<day|month>", fields[1])
+			}
+			out.InterestRate, err = parsePercent(parts[0])
+			out.InterestPeriod = strings.ToLower(parts[1])
+		case "cap":
+			out.Cap, err = parsePercent(fields[1])
+		default:
+			return nil, errors.Errorf("unknown late fee clause %v", fields[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, out.Validate()
+}
+
+// parsePercent parses a percentage, with or without the percent sign, as a fraction
+func parsePercent(percent string) (string, error) {
+	dec, err := decimal.NewFromString(strings.TrimSuffix(percent, "%"))
+	if err != nil {
+		return "", errors.Errorf("bad percentage %v", percent)
+	}
+	return dec.Div(decimal.New(100, 0)).String(), nil
+}
+
+// Validate checks that the late fee policy is well formed
+func (p *LateFeePolicy) Validate() error {
+	if p.FlatFee != nil {
+		if err := p.FlatFee.Validate(); err != nil {
+			return errors.WithMessage(err, "late fee")
+		}
+	}
+	rate, err := decimal.NewFromString(p.InterestRate)
+	if err != nil {
+		return errors.Wrap(err, "late fee interest rate")
+	}
+	capRate, err := decimal.NewFromString(p.Cap)
+	if err != nil {
+		return errors.Wrap(err, "late fee cap")
+	}
+	switch {
+	case rate.Sign() < 0:
+		return errors.New("late fee interest rate cannot be negative")
+	case rate.Sign() > 0 && p.InterestPeriod != InterestDaily && p.InterestPeriod != InterestMonthly:
+		return errors.Errorf("late fee interest period must be %v or %v", InterestDaily, InterestMonthly)
+	case capRate.Sign() < 0:
+		return errors.New("late fee cap cannot be negative")
+	}
+	return nil
+}
+
+// periodsElapsed counts the whole interest periods from start until date
+func (p *LateFeePolicy) periodsElapsed(start, date time.Time) int {
+	n := 0
+	switch p.InterestPeriod {
+	case InterestDaily:
+		if date.After(start) {
+			n = int(date.Sub(start) / (24 * time.Hour))
+		}
+	case InterestMonthly:
+		for !start.AddDate(0, n+1, 0).After(date) {
+			n++
+		}
+	}
+	return n
+}
+
+// next returns the start of the period n periods after start
+func (p *LateFeePolicy) next(start time.Time, n int) time.Time {
+	if p.InterestPeriod == InterestMonthly {
+		return start.AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// Charges calculates the total late charges accrued, nil if none
+func (c *Context) Charges() (*AmtCurTime, error) {
+	return c.AccruedFee.Add(c.AccruedInterest)
+}
+
+// UnpaidPrincipal calculates the unpaid portion of the payable amount,
+//   payments are applied to any late charges before the principal while
+//   credit and realized exchange gains or losses are applied to the principal
+func (c *Context) UnpaidPrincipal() (*AmtCurTime, error) {
+	principal, err := c.Payable.Minus(c.Discount)
+	if err != nil {
+		return nil, err
+	}
+	principal, err = principal.Minus(c.Credited)
+	if err != nil {
+		return nil, err
+	}
+	fx, err := c.FXGainLoss()
+	if err != nil {
+		return nil, err
+	}
+	principal, err = principal.Add(fx)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := c.Charges()
+	if err != nil {
+		return nil, err
+	}
+	paid, err := c.Paid()
+	if err != nil || paid == nil {
+		return principal, err
+	}
+	paidPrincipal := paid
+	if charges != nil {
+		lte, err := paid.LTE(charges)
+		if err != nil || lte {
+			return principal, err
+		}
+		paidPrincipal, err = paid.Minus(charges)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return principal.Minus(paidPrincipal)
+}
+
+// Accrue adds the late charges due on the date to an open overdue invoice
+//   with a late fee policy. Interest accrues on the unpaid principal for each
+//   whole period since the due date. Returns true if the invoice was modified.
+func (c *Context) Accrue(date time.Time) (bool, error) {
+	p := c.LateFees
+	if p == nil || !c.Open || !date.After(c.Due) {
+		return false, nil
+	}
+	changed := false
+
+	//an unset accrual date does not survive encoding as the zero time
+	if c.AccruedThrough.Before(c.Due) {
+		c.AccruedThrough = c.Due
+		changed = true
+	}
+	zero, err := NewAmtCurTime(c.Payable.CurTime.Cur, c.Payable.CurTime.Date, decimal.New(0, 0))
+	if err != nil {
+		return changed, err
+	}
+
+	//flat fee is charged once
+	if c.AccruedFee == nil && p.FlatFee != nil {
+		c.AccruedFee = &AmtCurTime{CurrencyTime{p.FlatFee.CurTime.Cur, c.Due}, p.FlatFee.Amount}
+		changed = true
+	}
+
+	//interest on the unpaid principal for each whole period elapsed
+	rate, err := decimal.NewFromString(p.InterestRate)
+	if err != nil {
+		return changed, err
+	}
+	if n := p.periodsElapsed(c.AccruedThrough, date); n > 0 && rate.Sign() > 0 {
+		principal, err := c.UnpaidPrincipal()
+		if err != nil {
+			return changed, err
+		}
+		amt, err := decimal.NewFromString(principal.Amount)
+		if err != nil {
+			return changed, err
+		}
+		interest, err := NewAmtCurTime(principal.CurTime.Cur, principal.CurTime.Date,
+			amt.Mul(rate).Mul(decimal.New(int64(n), 0)))
+		if err != nil {
+			return changed, err
+		}
+		if c.AccruedInterest == nil {
+			c.AccruedInterest = zero
+		}
+		c.AccruedInterest, err = c.AccruedInterest.Add(interest)
+		if err != nil {
+			return changed, err
+		}
+		c.AccruedThrough = p.next(c.AccruedThrough, n)
+		changed = true
+	}
+
+	//limit the total charges to the cap, reducing interest before the fee
+	capRate, err := decimal.NewFromString(p.Cap)
+	if err != nil || capRate.Sign() == 0 || !changed {
+		return changed, err
+	}
+	payable, err := decimal.NewFromString(c.Payable.Amount)
+	if err != nil {
+		return changed, err
+	}
+	maxCharges, err := NewAmtCurTime(c.Payable.CurTime.Cur, c.Payable.CurTime.Date, payable.Mul(capRate))
+	if err != nil {
+		return changed, err
+	}
+	charges, err := c.Charges()
+	if err != nil || charges == nil {
+		return changed, err
+	}
+	over, err := charges.Minus(maxCharges)
+	if err != nil {
+		return changed, err
+	}
+	if gt, err := over.GT(zero); err != nil || !gt {
+		return changed, err
+	}
+	if c.AccruedInterest != nil {
+		reduce := over
+		gt, err := over.GT(c.AccruedInterest)
+		if err != nil {
+			return changed, err
+		}
+		if gt {
+			reduce = c.AccruedInterest
+		}
+		c.AccruedInterest, err = c.AccruedInterest.Minus(reduce)
+		if err != nil {
+			return changed, err
+		}
+		over, err = over.Minus(reduce)
+		if err != nil {
+			return changed, err
+		}
+	}
+	if gt, err := over.GT(zero); err != nil || !gt {
+		return changed, err
+	}
+	c.AccruedFee, err = c.AccruedFee.Minus(over)
+	return changed, err
+}
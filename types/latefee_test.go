@@ -0,0 +1,100 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/go-wire"
+)
+
+func TestParseLateFeePolicy(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	policy, err := ParseLateFeePolicy("fee 25USD, interest 1.5%/month, cap 10%")
+	require.Nil(err)
+	assert.Equal("25", policy.FlatFee.Amount)
+	assert.Equal("0.015", policy.InterestRate)
+	assert.Equal(InterestMonthly, policy.InterestPeriod)
+	assert.Equal("0.1", policy.Cap)
+
+	for _, bad := range []string{
+		"",
+		"fee",
+		"fee 25",
+		"interest 1.5%",
+		"interest 1.5%/week",
+		"interest -1%/day",
+		"cap -10%",
+		"penalty 10%",
+	} {
+		_, err = ParseLateFeePolicy(bad)
+		assert.NotNil(err, bad)
+	}
+}
+
+func TestAccrue(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	amt, err := ParseAmtCurTime("1000USD", date)
+	require.Nil(err)
+	due := date.AddDate(0, 0, 30)
+	newCtx := func(policy string) *Context {
+		ctx := NewContract(nil, "foo", "bar", "", "", "USD", due, amt, amt).Ctx
+		ctx.LateFees, err = ParseLateFeePolicy(policy)
+		require.Nil(err)
+		return ctx
+	}
+
+	//nothing accrues until overdue
+	ctx := newCtx("fee 25USD, interest 1%/month")
+	changed, err := ctx.Accrue(due)
+	require.Nil(err)
+	assert.False(changed)
+
+	//the flat fee is charged once and interest accrues per whole period
+	changed, err = ctx.Accrue(due.AddDate(0, 0, 1))
+	require.Nil(err)
+	assert.True(changed)
+	assert.Equal("25", ctx.AccruedFee.Amount)
+	assert.Nil(ctx.AccruedInterest)
+	_, err = ctx.Accrue(due.AddDate(0, 2, 1))
+	require.Nil(err)
+	assert.Equal("25", ctx.AccruedFee.Amount)
+	assert.Equal("20", ctx.AccruedInterest.Amount)
+	unpaid, err := ctx.Unpaid()
+	require.Nil(err)
+	assert.Equal("1045", unpaid.Amount)
+
+	//payments settle the charges before the principal
+	fund, err := ParseAmtCurTime("100USD", date)
+	require.Nil(err)
+	_, err = ctx.Pay("tx", fund, due.AddDate(0, 2, 1))
+	require.Nil(err)
+	principal, err := ctx.UnpaidPrincipal()
+	require.Nil(err)
+	assert.Equal("945", principal.Amount)
+	_, err = ctx.Accrue(due.AddDate(0, 3, 1))
+	require.Nil(err)
+	assert.Equal("29.45", ctx.AccruedInterest.Amount)
+
+	//interest accrues from the due date once read back from the store
+	var stored Context
+	err = wire.ReadBinaryBytes(wire.BinaryBytes(*newCtx("interest 1%/month")), &stored)
+	require.Nil(err)
+	_, err = stored.Accrue(due.AddDate(0, 1, 1))
+	require.Nil(err)
+	assert.Equal("10", stored.AccruedInterest.Amount)
+
+	//charges are limited by the cap, interest first
+	ctx = newCtx("fee 25USD, interest 1%/day, cap 5%")
+	_, err = ctx.Accrue(due.AddDate(0, 0, 10))
+	require.Nil(err)
+	assert.Equal("25", ctx.AccruedFee.Amount)
+	assert.Equal("25", ctx.AccruedInterest.Amount)
+	charges, err := ctx.Charges()
+	require.Nil(err)
+	assert.Equal("50", charges.Amount)
+}
@@ -0,0 +1,180 @@
+package types
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// LineItem is a single itemised line of an invoice
+type LineItem struct {
+	Description string
+	Quantity    string      //Number of units, may be fractional (eg. hours)
+	UnitPrice   *AmtCurTime //Price of a single unit
+	TaxRate     string      //Fraction of the discounted subtotal charged as tax, eg. 0.05 for 5%, empty for the sender's jurisdiction
+	Discount    string      //Fraction of the subtotal discounted, eg. 0.1 for 10%
+}
+
+// NewLineItem creates a new validated line item, an empty discount is
+//   interpreted as zero. An empty tax rate leaves the tax of the line to the
+//   jurisdiction of the sender, the line itself charges no tax.
+func NewLineItem(Description, Quantity string, UnitPrice *AmtCurTime,
+	TaxRate, Discount string) (*LineItem, error) {
+
+	if len(Discount) == 0 {
+		Discount = "0"
+	}
+	item := &LineItem{
+		Description: Description,
+		Quantity:    Quantity,
+		UnitPrice:   UnitPrice,
+		TaxRate:     TaxRate,
+		Discount:    Discount,
+	}
+	return item, item.Validate()
+}
+
+// Validate checks that the line item is well formed
+func (l *LineItem) Validate() error {
+	if len(l.Description) == 0 {
+		return errors.New("line item must have a description")
+	}
+	if l.UnitPrice == nil {
+		return errors.Errorf("line item %v must have a unit price", l.Description)
+	}
+	if err := l.UnitPrice.Validate(); err != nil {
+		return errors.WithMessage(err, "line item "+l.Description)
+	}
+	qty, taxRate, discount, err := l.decimals()
+	if err != nil {
+		return err
+	}
+	switch {
+	case qty.Sign() <= 0:
+		return errors.Errorf("line item %v must have a positive quantity", l.Description)
+	case taxRate.Sign() < 0:
+		return errors.Errorf("line item %v cannot have a negative tax rate", l.Description)
+	case discount.Sign() < 0 || discount.GreaterThan(decimal.New(1, 0)):
+		return errors.Errorf("line item %v discount must be between 0 and 1", l.Description)
+	}
+	return nil
+}
+
+// HasTaxRate returns true if the line sets its own tax rate
+func (l *LineItem) HasTaxRate() bool {
+	return len(l.TaxRate) > 0
+}
+
+func (l *LineItem) decimals() (qty, taxRate, discount decimal.Decimal, err error) {
+	qty, err = decimal.NewFromString(l.Quantity)
+	if err != nil {
+		return qty, taxRate, discount, errors.Wrapf(err, "line item %v quantity", l.Description)
+	}
+	if l.HasTaxRate() {
+		taxRate, err = decimal.NewFromString(l.TaxRate)
+		if err != nil {
+			return qty, taxRate, discount, errors.Wrapf(err, "line item %v tax rate", l.Description)
+		}
+	}
+	discount, err = decimal.NewFromString(l.Discount)
+	if err != nil {
+		return qty, taxRate, discount, errors.Wrapf(err, "line item %v discount", l.Description)
+	}
+	return
+}
+
+// Subtotal calculates the discounted value of the line before tax,
+//   rounded to the minor units of the unit price currency
+func (l *LineItem) Subtotal() (*AmtCurTime, error) {
+	qty, _, discount, err := l.decimals()
+	if err != nil {
+		return nil, err
+	}
+	price, err := decimal.NewFromString(l.UnitPrice.Amount)
+	if err != nil {
+		return nil, err
+	}
+	subtotal := price.Mul(qty).Mul(decimal.New(1, 0).Sub(discount))
+	return NewAmtCurTime(l.UnitPrice.CurTime.Cur, l.UnitPrice.CurTime.Date, subtotal)
+}
+
+// Tax calculates the tax charged on the line
+func (l *LineItem) Tax() (*AmtCurTime, error) {
+	_, taxRate, _, err := l.decimals()
+	if err != nil {
+		return nil, err
+	}
+	subtotal, err := l.Subtotal()
+	if err != nil {
+		return nil, err
+	}
+	amt, err := decimal.NewFromString(subtotal.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return NewAmtCurTime(subtotal.CurTime.Cur, subtotal.CurTime.Date, amt.Mul(taxRate))
+}
+
+// Total calculates the value of the line including tax
+func (l *LineItem) Total() (*AmtCurTime, error) {
+	subtotal, err := l.Subtotal()
+	if err != nil {
+		return nil, err
+	}
+	tax, err := l.Tax()
+	if err != nil {
+		return nil, err
+	}
+	return subtotal.Add(tax)
+}
+
+// SumLineItems calculates the total value of all the line items,
+//   all line items must be priced in the same currency
+func SumLineItems(items []*LineItem) (sum *AmtCurTime, err error) {
+	return sumLineItems(items, (*LineItem).Total)
+}
+
+// SumLineSubtotals calculates the total value of all the line items before tax
+func SumLineSubtotals(items []*LineItem) (sum *AmtCurTime, err error) {
+	return sumLineItems(items, (*LineItem).Subtotal)
+}
+
+func sumLineItems(items []*LineItem, value func(*LineItem) (*AmtCurTime, error)) (sum *AmtCurTime, err error) {
+	if len(items) == 0 {
+		return nil, errors.New("no line items to sum")
+	}
+	for _, item := range items {
+		total, err := value(item)
+		if err != nil {
+			return nil, err
+		}
+		if sum != nil && sum.CurTime.Cur != total.CurTime.Cur {
+			return nil, errors.Errorf("line items must share a currency, found %v and %v",
+				sum.CurTime.Cur, total.CurTime.Cur)
+		}
+		sum, err = sum.Add(total)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
+
+// ParseLineItems parses the line items of an invoice tx,
+//   unit prices are valued at the invoice date
+func ParseLineItems(txItems []TxLineItem, date time.Time) (items []*LineItem, err error) {
+	for _, txItem := range txItems {
+		price, err := ParseAmtCurTime(txItem.UnitPrice, date)
+		if err != nil {
+			return nil, errors.WithMessage(err, "line item "+txItem.Description)
+		}
+		item, err := NewLineItem(txItem.Description, txItem.Quantity, price,
+			txItem.TaxRate, txItem.Discount)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
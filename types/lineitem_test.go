@@ -0,0 +1,57 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineItems(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	items, err := ParseLineItems([]TxLineItem{
+		{Description: "design", Quantity: "10", UnitPrice: "150USD", TaxRate: "0.05", Discount: "0.1"},
+		{Description: "hosting", Quantity: "1", UnitPrice: "19.99USD"},
+		{Description: "hours", Quantity: "2.5", UnitPrice: "40.01USD", TaxRate: "0.13"},
+	}, date)
+	require.Nil(err)
+
+	//10*150 = 1500, less 10% = 1350, plus 5% tax = 1417.5
+	total, err := items[0].Total()
+	require.Nil(err)
+	assert.Equal("1417.5", total.Amount)
+
+	//2.5*40.01 = 100.025 rounds (half even) to 100.02, 13% tax = 13.0026 rounds to 13
+	tax, err := items[2].Tax()
+	require.Nil(err)
+	assert.Equal("13", tax.Amount)
+
+	sum, err := SumLineItems(items)
+	require.Nil(err)
+	assert.Equal("1550.51", sum.Amount)
+	assert.Equal("USD", sum.CurTime.Cur)
+
+	//invalid line items
+	for _, bad := range []TxLineItem{
+		{Description: "", Quantity: "1", UnitPrice: "1USD"},
+		{Description: "zero", Quantity: "0", UnitPrice: "1USD"},
+		{Description: "qty", Quantity: "abc", UnitPrice: "1USD"},
+		{Description: "price", Quantity: "1", UnitPrice: "1.001USD"},
+		{Description: "tax", Quantity: "1", UnitPrice: "1USD", TaxRate: "-0.1"},
+		{Description: "discount", Quantity: "1", UnitPrice: "1USD", Discount: "1.5"},
+	} {
+		_, err = ParseLineItems([]TxLineItem{bad}, date)
+		assert.NotNil(err, bad.Description)
+	}
+
+	//line items must share a currency
+	items, err = ParseLineItems([]TxLineItem{
+		{Description: "usd", Quantity: "1", UnitPrice: "1USD"},
+		{Description: "eur", Quantity: "1", UnitPrice: "1EUR"},
+	}, date)
+	require.Nil(err)
+	_, err = SumLineItems(items)
+	assert.NotNil(err)
+}
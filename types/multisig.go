@@ -0,0 +1,124 @@
+package types
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PendingAction is an action of a multi-signature profile which is awaiting
+//   the approval of its signers, the action runs once the threshold is reached
+type PendingAction struct {
+	ID        []byte    //hash of the tx of the action
+	Profile   string    //name of the multi-signature profile
+	Proposer  []byte    //address which sent the tx of the action
+	TxBytes   []byte    //tx of the action, including its type byte
+	Approvals [][]byte  //signers which have approved the action
+	Proposed  time.Time //block time the action was proposed
+	Executed  bool      //the threshold was reached and the action has run
+}
+
+// HasApproved returns true if the signer has approved the action
+func (a *PendingAction) HasApproved(signer []byte) bool {
+	return BytesIn(a.Approvals, signer)
+}
+
+// Signatures returns the number of approvals by current signers of the profile
+func (a *PendingAction) Signatures(p *Profile) int {
+	n := 0
+	for _, approval := range a.Approvals {
+		if p.Controls(approval) {
+			n++
+		}
+	}
+	return n
+}
+
+// IsMultisig returns true if actions of the profile need more than one signer
+func (p *Profile) IsMultisig() bool {
+	return p.Threshold > 1
+}
+
+// Controls returns true if the address is the profile address or one of its signers
+func (p *Profile) Controls(address []byte) bool {
+	return bytes.Equal(p.Address, address) || BytesIn(p.Signers, address)
+}
+
+// Addresses returns the addresses controlling the profile
+func (p *Profile) Addresses() [][]byte {
+	if BytesIn(p.Signers, p.Address) {
+		return p.Signers
+	}
+	return append([][]byte{p.Address}, p.Signers...)
+}
+
+// RotateKey moves the key of the profile held by the old address to the new
+//   address, the old address may be the profile address or one of its signers
+func (p *Profile) RotateKey(old, new []byte) error {
+	switch {
+	case len(new) == 0:
+		return errors.New("the new address must not be empty")
+	case !p.Controls(old):
+		return errors.Errorf("%X does not control the profile", old)
+	case p.Controls(new):
+		return errors.Errorf("%X already controls the profile", new)
+	}
+	if bytes.Equal(p.Address, old) {
+		p.Address = new
+	}
+	for i, signer := range p.Signers {
+		if bytes.Equal(signer, old) {
+			p.Signers[i] = new
+		}
+	}
+	return nil
+}
+
+// RequiresSignatures returns true if an action of the amount requires the
+//   threshold of signers, amounts in a currency other than the limit's and
+//   actions without an amount always require it
+func (p *Profile) RequiresSignatures(amt *AmtCurTime) bool {
+	if !p.IsMultisig() {
+		return false
+	}
+	if p.Limit == nil || amt == nil || amt.CurTime.Cur != p.Limit.CurTime.Cur {
+		return true
+	}
+	gt, err := amt.GT(p.Limit)
+	return err != nil || gt
+}
+
+// ValidateSigners checks the signers and threshold of a multi-signature profile
+func (p *Profile) ValidateSigners() error {
+	switch {
+	case p.Threshold < 0:
+		return errors.New("the signature threshold must be non-negative")
+	case p.Threshold > len(p.Signers) && p.Threshold > 1:
+		return errors.Errorf("the signature threshold %v exceeds the %v signers", p.Threshold, len(p.Signers))
+	case len(p.Signers) > 0 && !BytesIn(p.Signers, p.Address):
+		return errors.New("the signers must include the profile address")
+	}
+	for i, signer := range p.Signers {
+		if len(signer) == 0 {
+			return errors.New("signers must have an address")
+		}
+		if BytesIn(p.Signers[:i], signer) {
+			return errors.Errorf("duplicate signer %X", signer)
+		}
+	}
+	if p.Limit != nil {
+		return p.Limit.Validate()
+	}
+	return nil
+}
+
+// BytesIn returns whether the bytes are an element of the list
+func BytesIn(list [][]byte, b []byte) bool {
+	for _, el := range list {
+		if bytes.Equal(el, b) {
+			return true
+		}
+	}
+	return false
+}
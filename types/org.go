@@ -0,0 +1,167 @@
+package types
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//nolint Roles of the members of an organisation profile
+const (
+	RoleSubmitter = "submitter" //may send expenses to the organisation
+	RoleApprover  = "approver"  //may approve invoices sent to the organisation
+	RolePayer     = "payer"     //may pay invoices on behalf of the organisation
+	RoleAdmin     = "admin"     //may manage the members and approval chain
+)
+
+// ValidateRole checks that the role is one of the member roles
+func ValidateRole(role string) error {
+	switch role {
+	case RoleSubmitter, RoleApprover, RolePayer, RoleAdmin:
+		return nil
+	}
+	return errors.Errorf("unknown member role %v, must be one of %v, %v, %v or %v",
+		role, RoleSubmitter, RoleApprover, RolePayer, RoleAdmin)
+}
+
+// Member of an organisation profile and the roles it holds
+type Member struct {
+	Address []byte
+	Roles   []string
+}
+
+// ApprovalStep is a step of the approval chain of expenses sent to an
+//   organisation, which may be approved by any one of its approvers
+type ApprovalStep struct {
+	Name      string
+	Approvers [][]byte
+}
+
+// StepApproval records the approval of a step of an approval chain
+type StepApproval struct {
+	Step     string
+	Approver []byte
+	Date     time.Time
+	Comment  string
+}
+
+// IsOrg returns true if the profile is an organisation with members
+func (p *Profile) IsOrg() bool {
+	return len(p.Members) > 0
+}
+
+// HasRole returns true if the address holds the role in the profile,
+//   the addresses controlling the profile hold every role
+func (p *Profile) HasRole(address []byte, role string) bool {
+	if p.Controls(address) {
+		return true
+	}
+	for _, member := range p.Members {
+		if !bytes.Equal(member.Address, address) {
+			continue
+		}
+		for _, r := range member.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetMember adds a member to the profile or replaces the roles of an existing member
+func (p *Profile) SetMember(address []byte, roles []string) error {
+	switch {
+	case len(address) == 0:
+		return errors.New("a member must have an address")
+	case p.Controls(address):
+		return errors.New("the owners of the profile hold every role and cannot be members")
+	case len(roles) == 0:
+		return errors.New("a member must hold at least one role")
+	}
+	for _, role := range roles {
+		if err := ValidateRole(role); err != nil {
+			return err
+		}
+	}
+	for i, member := range p.Members {
+		if bytes.Equal(member.Address, address) {
+			p.Members[i].Roles = roles
+			return nil
+		}
+	}
+	p.Members = append(p.Members, Member{Address: address, Roles: roles})
+	return nil
+}
+
+// RemoveMember removes a member from the profile
+func (p *Profile) RemoveMember(address []byte) error {
+	for i, member := range p.Members {
+		if bytes.Equal(member.Address, address) {
+			p.Members = append(p.Members[:i], p.Members[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("%X is not a member of %v", address, p.Name)
+}
+
+// ValidateApprovalChain checks that the steps of an approval chain are
+//   uniquely named and are each approved by approvers of the profile
+func (p *Profile) ValidateApprovalChain(steps []ApprovalStep) error {
+	names := make(map[string]bool)
+	for _, step := range steps {
+		switch {
+		case len(step.Name) == 0:
+			return errors.New("approval steps must have a name")
+		case names[step.Name]:
+			return errors.Errorf("duplicate approval step %v", step.Name)
+		case len(step.Approvers) == 0:
+			return errors.Errorf("approval step %v has no approvers", step.Name)
+		}
+		names[step.Name] = true
+		for _, approver := range step.Approvers {
+			if !p.HasRole(approver, RoleApprover) {
+				return errors.Errorf("%X of approval step %v is not an approver of %v",
+					approver, step.Name, p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// NextStep returns the step of the approval chain awaiting approval,
+//   nil if the chain is complete
+func (c *Context) NextStep() *ApprovalStep {
+	if len(c.ChainApprovals) >= len(c.Chain) {
+		return nil
+	}
+	return &c.Chain[len(c.ChainApprovals)]
+}
+
+// ApproveStep records the approval of the next step of the approval chain
+//   of a pending invoice, the invoice is approved with the final step
+func (c *Context) ApproveStep(approver []byte, date time.Time, comment string) error {
+	step := c.NextStep()
+	switch {
+	case !c.Open:
+		return errors.New("only an open invoice may be approved")
+	case c.Approval != ApprovalPending:
+		return errors.New("only a pending invoice may be approved through its approval chain")
+	case step == nil:
+		return errors.New("the approval chain is complete")
+	}
+	if !BytesIn(step.Approvers, approver) {
+		return errors.Errorf("%X is not an approver of the %v step", approver, step.Name)
+	}
+	c.ChainApprovals = append(c.ChainApprovals, StepApproval{
+		Step:     step.Name,
+		Approver: approver,
+		Date:     date,
+		Comment:  comment,
+	})
+	if c.NextStep() == nil {
+		return c.Approve(comment)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// RoundingMode determines how an amount is rounded to the minor units of its currency
+type RoundingMode int
+
+//nolint Rounding modes
+const (
+	RoundHalfUp   RoundingMode = iota //round half away from zero
+	RoundHalfEven                     //round half to the nearest even digit (bankers rounding)
+	RoundDown                         //truncate towards zero
+)
+
+// Currency describes the precision and rounding rules of a denomination
+type Currency struct {
+	Code       string
+	MinorUnits int32 //number of decimal places of the smallest unit
+	Rounding   RoundingMode
+}
+
+// currencies is the registry of all denominations which may be used in amounts
+var currencies = make(map[string]Currency)
+
+func init() {
+	//ISO 4217 fiat currencies
+	for _, code := range []string{"AUD", "BGN", "BRL", "CAD", "CHF", "CNY", "CZK",
+		"DKK", "EUR", "GBP", "HKD", "HRK", "HUF", "IDR", "ILS", "INR", "MXN", "MYR",
+		"NOK", "NZD", "PHP", "PLN", "RON", "RUB", "SEK", "SGD", "THB", "TRY", "USD", "ZAR"} {
+		registerCurrency(Currency{code, 2, RoundHalfEven})
+	}
+	for _, code := range []string{"ISK", "JPY", "KRW"} {
+		registerCurrency(Currency{code, 0, RoundHalfEven})
+	}
+	for _, code := range []string{"BHD", "KWD", "OMR"} {
+		registerCurrency(Currency{code, 3, RoundHalfEven})
+	}
+
+	//Crypto currencies, in their smallest on-chain units (satoshi, wei, etc.)
+	registerCurrency(Currency{"BTC", 8, RoundDown})
+	registerCurrency(Currency{"ETH", 18, RoundDown})
+	registerCurrency(Currency{"ATOM", 6, RoundDown})
+	registerCurrency(Currency{"USDT", 6, RoundDown})
+	registerCurrency(Currency{"USDC", 6, RoundDown})
+	registerCurrency(Currency{"DAI", 18, RoundDown})
+}
+
+// registerCurrency adds a denomination to the currency registry, the registry
+//   feeds consensus so it is only filled by init and never replaced
+func registerCurrency(currency Currency) {
+	if _, ok := currencies[currency.Code]; ok {
+		panic("currency " + currency.Code + " is already registered")
+	}
+	currencies[currency.Code] = currency
+}
+
+// GetCurrency retrieves a denomination from the currency registry
+func GetCurrency(code string) (Currency, error) {
+	currency, ok := currencies[code]
+	if !ok {
+		return currency, errors.Errorf("unknown currency %v", code)
+	}
+	return currency, nil
+}
+
+// Round rounds the amount to the minor units of the currency
+func (c Currency) Round(amt decimal.Decimal) decimal.Decimal {
+	switch c.Rounding {
+	case RoundDown:
+		return amt.Truncate(c.MinorUnits)
+	case RoundHalfEven:
+		trunc := amt.Truncate(c.MinorUnits)
+		half := decimal.New(5, -c.MinorUnits-1)
+		if !amt.Sub(trunc).Abs().Equal(half) {
+			return amt.Round(c.MinorUnits)
+		}
+		//on a tie keep the truncated value if its last digit is even
+		lastDigit := trunc.Mul(decimal.New(1, c.MinorUnits)).Mod(decimal.New(2, 0))
+		if lastDigit.Sign() == 0 {
+			return trunc
+		}
+		return amt.Round(c.MinorUnits)
+	default:
+		return amt.Round(c.MinorUnits)
+	}
+}
+
+// Validate checks that the amount has no more precision than the minor units of the currency
+func (c Currency) Validate(amt decimal.Decimal) error {
+	if !amt.Equal(amt.Truncate(c.MinorUnits)) {
+		return errors.Errorf("amount %v has more than %v decimal places allowed for %v",
+			amt.String(), c.MinorUnits, c.Code)
+	}
+	return nil
+}
@@ -1,24 +1,39 @@
 package types
 
 import (
+	"encoding/hex"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"github.com/tendermint/tmlibs/merkle"
 )
 
 // Profile is the state used to store an invoicer profile
 type Profile struct {
-	Address         []byte //identifier for querying
-	Name            string //identifier for querying
-	AcceptedCur     string //currency you will accept payment in
-	DepositInfo     string //default deposit information (mostly for fiat)
-	DueDurationDays int    //default duration until a sent invoice due date
-	Active          bool   //default duration until a sent invoice due date
+	Address         []byte         //identifier for querying
+	Name            string         //identifier for querying
+	AcceptedCur     string         //currency you will accept payment in
+	DepositInfo     string         //default deposit information (mostly for fiat)
+	DueDurationDays int            //default duration until a sent invoice due date
+	Active          bool           //default duration until a sent invoice due date
+	TaxJurisdiction string         //jurisdiction the profile is registered for tax in, eg. DE or CA-ON
+	TaxID           string         //tax registration ID, eg. VAT or GST number
+	Terms           *PaymentTerms  //default payment terms of sent invoices, nil if none
+	LateFees        *LateFeePolicy //default late fee policy of sent invoices, nil if none
+	TrustedSenders  []string       //profile names whose invoices are approved automatically
+	Members         []Member       //members of an organisation profile, none for an individual
+	ApprovalChain   []ApprovalStep //steps approving expenses sent to an organisation, in order
+	Signers         [][]byte       //addresses controlling a multi-signature profile, including Address
+	Threshold       int            //number of signers required to approve actions, 0 if not multi-signature
+	Limit           *AmtCurTime    //invoices and payments above the limit need the threshold, nil for all
 }
 
 // NewProfile create a new active profile
 func NewProfile(Address []byte, Name, AcceptedCur, DepositInfo string,
-	DueDurationDays int) *Profile {
+	DueDurationDays int, TaxJurisdiction, TaxID string, Terms *PaymentTerms,
+	LateFees *LateFeePolicy, TrustedSenders []string, Signers [][]byte, Threshold int,
+	Limit *AmtCurTime) *Profile {
 	return &Profile{
 		Address:         Address,
 		Name:            Name,
@@ -26,9 +41,27 @@ func NewProfile(Address []byte, Name, AcceptedCur, DepositInfo string,
 		DepositInfo:     DepositInfo,
 		DueDurationDays: DueDurationDays,
 		Active:          true,
+		TaxJurisdiction: TaxJurisdiction,
+		TaxID:           TaxID,
+		Terms:           Terms,
+		LateFees:        LateFees,
+		TrustedSenders:  TrustedSenders,
+		Signers:         Signers,
+		Threshold:       Threshold,
+		Limit:           Limit,
 	}
 }
 
+// Trusts returns true if invoices from the sender are approved automatically
+func (p *Profile) Trusts(sender string) bool {
+	for _, name := range p.TrustedSenders {
+		if name == sender {
+			return true
+		}
+	}
+	return false
+}
+
 //////////////////////////////////////////////////////////////////////
 
 //nolint - Autogenerator code for the invoicer types
@@ -43,6 +76,7 @@ type InvoiceInner interface {
 //for checking errors at compile time
 var _ InvoiceInner = new(Contract)
 var _ InvoiceInner = new(Expense)
+var _ InvoiceInner = new(CreditNote)
 
 // Contract state struct of type Invoice
 type Contract struct {
@@ -50,6 +84,29 @@ type Contract struct {
 	Ctx *Context
 }
 
+//nolint Invoice approval states
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+//nolint Dispute resolutions, an open dispute has no resolution
+const (
+	DisputeWithdrawn = "withdrawn"
+	DisputeAdjusted  = "adjusted"
+	DisputeUpheld    = "upheld"
+)
+
+// Dispute of an invoice raised by its receiver
+type Dispute struct {
+	Reason     string    //Reason the receiver disputes the invoice
+	Evidence   []byte    //Hash of a document evidencing the dispute, may be empty
+	Date       time.Time //Block time the dispute was raised
+	Response   string    //Response of the sender to the dispute
+	Resolution string    //Resolution of the dispute, empty while the dispute is open
+}
+
 // Context struct used for hash to determine ID for invoices
 type Context struct {
 	Sender      string
@@ -59,44 +116,405 @@ type Context struct {
 	AcceptedCur string
 	Due         time.Time
 
-	Open     bool        //Is this invoice open
-	Invoiced *AmtCurTime //Amount Invoiced (likely fiat)
-	Payable  *AmtCurTime //Payable Amount (likely crypto)
-	Paid     *AmtCurTime //Amount Paid towards this invoice
+	Open        bool          //Is this invoice open
+	Voided      bool          //Was this invoice withdrawn by its sender, voided invoices are not open
+	VoidReason  string        //Reason the invoice was voided
+	Approval    string        //Approval of the invoice by its receiver, pending, approved or rejected
+	Comment     string        //Comment of the receiver on approving or rejecting the invoice
+	Disputes    []Dispute     //Disputes raised by the receiver, only the last may be open
+	Invoiced    *AmtCurTime   //Amount Invoiced (likely fiat)
+	Payable     *AmtCurTime   //Payable Amount (likely crypto)
+	Allocations []Allocation  //Append-only ledger of the payments allocated to this invoice
+	Conversion  *Conversion   //Exchange rates used to calculate Payable from Invoiced
+	LineItems   []*LineItem   //Itemised lines which the Invoiced amount is derived from
+	Tax         *TaxBreakdown //Tax calculated for the invoice from the profiles' jurisdictions
+	Terms       *PaymentTerms //Payment terms of the invoice, nil if none
+	Discount    *AmtCurTime   //Early payment discount taken when the invoice was settled
+	Credited    *AmtCurTime   //Credit applied to this invoice from credit notes
+
+	Chain          []ApprovalStep //Approval chain of the receiving organisation the invoice must pass
+	ChainApprovals []StepApproval //Approvals of the steps of the chain so far, in order
+
+	LateFees        *LateFeePolicy //Charges accrued once the invoice is overdue, nil if none
+	AccruedFee      *AmtCurTime    //Flat late fee charged
+	AccruedInterest *AmtCurTime    //Late interest charged
+	AccruedThrough  time.Time      //Date through which late interest has been charged
+
+	ScheduleID []byte //ID of the recurring schedule which issued this invoice, nil if none
+}
+
+// Allocation is the portion of a payment allocated to an invoice, for
+//   a credit note it is the portion of the credit applied to an invoice
+type Allocation struct {
+	TransactionID string      //Payment transaction ID, or the hex ID of the invoice credited
+	Amount        *AmtCurTime //Amount allocated in the payable currency
+	Date          time.Time   //Block time at which the allocation was recorded
+	Rate          string      //Exchange rate from the payment currency to the payable currency
+	FXGainLoss    *AmtCurTime //Realized exchange gain, or loss if negative, on settlement, nil if none
+}
+
+// Paid calculates the total allocated to the invoice, nil if nothing has been paid
+func (c *Context) Paid() (paid *AmtCurTime, err error) {
+	for _, allocation := range c.Allocations {
+		paid, err = paid.Add(allocation.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paid, nil
 }
 
 // Unpaid calculates the total remaining unpaid portion of an invoice
+//   including any accrued late charges, less any credit applied. For
+//   a credit note this is the credit which has not yet been applied.
 func (c *Context) Unpaid() (*AmtCurTime, error) {
-	return c.Payable.Minus(c.Paid)
+	charges, err := c.Charges()
+	if err != nil {
+		return nil, err
+	}
+	owed, err := c.Payable.Add(charges)
+	if err != nil {
+		return nil, err
+	}
+	paid, err := c.Paid()
+	if err != nil {
+		return nil, err
+	}
+	unpaid, err := owed.Minus(paid)
+	if err != nil {
+		return nil, err
+	}
+	unpaid, err = unpaid.Minus(c.Credited)
+	if err != nil {
+		return nil, err
+	}
+	unpaid, err = unpaid.Minus(c.Discount)
+	if err != nil {
+		return nil, err
+	}
+
+	//a realized gain was received in excess of the unpaid amount
+	fx, err := c.FXGainLoss()
+	if err != nil {
+		return nil, err
+	}
+	return unpaid.Add(fx)
+}
+
+// AvailableDiscount calculates the early payment discount available if
+//   the invoice is settled on the date, nil if no discount is available
+func (c *Context) AvailableDiscount(date time.Time) (*AmtCurTime, error) {
+	if !c.Open || c.Terms == nil || c.Discount != nil || c.Invoiced == nil {
+		return nil, nil
+	}
+	discount, err := c.Terms.Discount(c.Payable, c.Invoiced.CurTime.Date, date)
+	if err != nil || discount.Amount == "0" {
+		return nil, err
+	}
+	return discount, nil
 }
 
-// Pay makes the maximum payment to the invoice from the fund
-//   are then reduced and returned through the variable leftover
-func (c *Context) Pay(fund *AmtCurTime) (leftover *AmtCurTime, err error) {
+// UnpaidOn calculates the amount required to settle the invoice on the date,
+//   which is reduced by any early payment discount available on that date
+func (c *Context) UnpaidOn(date time.Time) (*AmtCurTime, error) {
 	unpaid, err := c.Unpaid()
+	if err != nil {
+		return nil, err
+	}
+	discount, err := c.AvailableDiscount(date)
+	if err != nil {
+		return nil, err
+	}
+	return unpaid.Minus(discount)
+}
+
+// Pay allocates the maximum payment to the invoice from the fund of the
+//   payment transaction on the date, the remaining funds are returned through
+//   the variable leftover. If the fund settles the invoice within the discount
+//   window the discount is taken.
+func (c *Context) Pay(transactionID string, fund *AmtCurTime,
+	date time.Time) (leftover *AmtCurTime, err error) {
+
+	if fund.CurTime.Cur != c.Payable.CurTime.Cur {
+		return fund, errors.Errorf("cannot pay %v invoice in %v without an exchange rate",
+			c.Payable.CurTime.Cur, fund.CurTime.Cur)
+	}
+	return c.PayAt(transactionID, fund, "1", date)
+}
+
+// UnpaidAt calculates the amount in the currency cur required to settle the
+//   invoice on the date, where the rate is the value of one unit of cur in the
+//   payable currency
+func (c *Context) UnpaidAt(cur, rate string, date time.Time) (*AmtCurTime, error) {
+	r, err := decimal.NewFromString(rate)
+	if err != nil || r.Sign() <= 0 {
+		return nil, errors.Errorf("bad exchange rate %v", rate)
+	}
+	unpaid, err := c.UnpaidOn(date)
+	if err != nil {
+		return nil, err
+	}
+	amt, err := decimal.NewFromString(unpaid.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return NewAmtCurTime(cur, date, amt.Div(r))
+}
+
+// PayAt allocates the maximum payment to the invoice from the fund of the
+//   payment transaction in any currency, converted to the payable currency at
+//   the rate, the remaining funds are returned through the variable leftover.
+//   The fund settles the invoice once it covers the unpaid amount converted to
+//   the fund currency, any difference between the converted fund and the unpaid
+//   amount due to the rounding of either currency is recorded as a realized
+//   exchange gain or loss on the allocation.
+func (c *Context) PayAt(transactionID string, fund *AmtCurTime, rate string,
+	date time.Time) (leftover *AmtCurTime, err error) {
+
+	r, err := decimal.NewFromString(rate)
+	if err != nil {
+		return fund, err
+	}
+	unpaid, err := c.UnpaidOn(date)
 	if err != nil {
 		return fund, err
 	}
-	gte, err := fund.GTE(unpaid)
+	required, err := c.UnpaidAt(fund.CurTime.Cur, rate, date)
+	if err != nil {
+		return fund, err
+	}
+	used := fund
+	gte, err := fund.GTE(required)
 	if err != nil {
 		return fund, err
 	}
 	if gte {
-		c.Paid = c.Payable
-		c.Open = false
-		fund, err = fund.Minus(c.Payable)
+		used = required
+	}
+	usedAmt, err := decimal.NewFromString(used.Amount)
+	if err != nil {
+		return fund, err
+	}
+	allocated, err := NewAmtCurTime(unpaid.CurTime.Cur, unpaid.CurTime.Date, usedAmt.Mul(r))
+	if err != nil {
+		return fund, err
+	}
+
+	//settled once the fund covers the unpaid amount in either currency
+	var fx *AmtCurTime
+	if !gte {
+		gte, err = allocated.GTE(unpaid)
 		if err != nil {
 			return fund, err
 		}
-	} else {
-		//TODO better way of duplicating value of fund here
-		c.Paid = &AmtCurTime{CurrencyTime{fund.CurTime.Cur, fund.CurTime.Date}, fund.Amount}
-		fund.Amount = "0" //empty the fund
 	}
-	return fund, nil
+	if gte {
+		c.Discount, err = c.AvailableDiscount(date)
+		if err != nil {
+			return fund, err
+		}
+		c.Open = false
+		if fx, err = allocated.Minus(unpaid); err != nil {
+			return fund, err
+		}
+		if fx.Amount == "0" {
+			fx = nil
+		}
+	}
+	if allocated.Amount != "0" {
+		c.Allocations = append(c.Allocations, Allocation{
+			TransactionID: transactionID,
+			Amount:        allocated,
+			Date:          date,
+			Rate:          rate,
+			FXGainLoss:    fx,
+		})
+	}
+	return fund.Minus(used)
+}
+
+// FXGainLoss calculates the net realized exchange gain, or loss if negative,
+//   of the allocations to the invoice, nil if none
+func (c *Context) FXGainLoss() (fx *AmtCurTime, err error) {
+	for _, allocation := range c.Allocations {
+		fx, err = fx.Add(allocation.FXGainLoss)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fx, nil
+}
+
+// Unpay unwinds the allocations of the payment transaction by recording a
+//   negative allocation of their total on the date, the invoice is reopened
+//   and any early payment discount taken on settlement is withdrawn
+func (c *Context) Unpay(transactionID string, date time.Time) error {
+	var amount, fx *AmtCurTime
+	var err error
+	for _, allocation := range c.Allocations {
+		if allocation.TransactionID != transactionID {
+			continue
+		}
+		if amount, err = amount.Add(allocation.Amount); err != nil {
+			return err
+		}
+		if fx, err = fx.Add(allocation.FXGainLoss); err != nil {
+			return err
+		}
+	}
+	if amount == nil {
+		return errors.Errorf("no allocations of transaction %v to unwind", transactionID)
+	}
+	zero := &AmtCurTime{amount.CurTime, "0"}
+	negative, err := zero.Minus(amount)
+	if err != nil {
+		return err
+	}
+	if fx != nil {
+		if fx, err = zero.Minus(fx); err != nil {
+			return err
+		}
+	}
+	c.Allocations = append(c.Allocations, Allocation{
+		TransactionID: transactionID,
+		Amount:        negative,
+		Date:          date,
+		Rate:          "1",
+		FXGainLoss:    fx,
+	})
+	c.Open = true
+	c.Discount = nil
+	return nil
 }
 
-// NewContract creates a new open Contract invoice
+// Void withdraws an open invoice which has received no payments or credit,
+//   payments which have all been reversed do not count
+func (c *Context) Void(reason string) error {
+	switch {
+	case len(reason) == 0:
+		return errors.New("a reason is required to void an invoice")
+	case !c.Open:
+		return errors.New("only an open invoice may be voided")
+	}
+	paid, err := c.Paid()
+	if err != nil {
+		return err
+	}
+	if (paid != nil && paid.Amount != "0") || c.Credited != nil {
+		return errors.New("cannot void an invoice which has received payments or credit")
+	}
+	c.Open = false
+	c.Voided = true
+	c.VoidReason = reason
+	return nil
+}
+
+// Approved returns true if the receiver has approved the invoice
+func (c *Context) Approved() bool {
+	return c.Approval == ApprovalApproved
+}
+
+// Approve records the receiver's approval of an open invoice
+func (c *Context) Approve(comment string) error {
+	switch {
+	case !c.Open:
+		return errors.New("only an open invoice may be approved")
+	case c.Approved():
+		return errors.New("the invoice is already approved")
+	}
+	c.Approval = ApprovalApproved
+	c.Comment = comment
+	return nil
+}
+
+// Reject records the receiver's rejection of a pending invoice
+//   which has received no payments
+func (c *Context) Reject(comment string) error {
+	switch {
+	case !c.Open:
+		return errors.New("only an open invoice may be rejected")
+	case c.Approval != ApprovalPending:
+		return errors.New("only a pending invoice may be rejected")
+	case len(c.Allocations) > 0 || c.Credited != nil:
+		return errors.New("cannot reject an invoice which has received payments or credit")
+	}
+	c.Approval = ApprovalRejected
+	c.Comment = comment
+	return nil
+}
+
+// Disputed returns true if the invoice has an open dispute
+func (c *Context) Disputed() bool {
+	return len(c.Disputes) > 0 && len(c.Disputes[len(c.Disputes)-1].Resolution) == 0
+}
+
+// Dispute records a dispute of an open invoice by its receiver
+func (c *Context) Dispute(reason string, evidence []byte, date time.Time) error {
+	switch {
+	case len(reason) == 0:
+		return errors.New("a reason is required to dispute an invoice")
+	case !c.Open:
+		return errors.New("only an open invoice may be disputed")
+	case c.Disputed():
+		return errors.New("the invoice is already disputed")
+	}
+	c.Disputes = append(c.Disputes, Dispute{
+		Reason:   reason,
+		Evidence: evidence,
+		Date:     date,
+	})
+	return nil
+}
+
+// Resolve records the resolution of the open dispute of the invoice,
+//   an invoice withdrawn by its sender is voided with the response as reason
+func (c *Context) Resolve(resolution, response string) error {
+	if !c.Disputed() {
+		return errors.New("the invoice has no open dispute")
+	}
+	switch resolution {
+	case DisputeWithdrawn:
+		if err := c.Void(response); err != nil {
+			return err
+		}
+	case DisputeAdjusted, DisputeUpheld:
+	default:
+		return errors.Errorf("unknown dispute resolution %v", resolution)
+	}
+	dispute := &c.Disputes[len(c.Disputes)-1]
+	dispute.Response = response
+	dispute.Resolution = resolution
+	return nil
+}
+
+// ApplyCredit reduces the unpaid portion of an open invoice by up to the
+//   credit, the remaining credit is returned through the variable leftover.
+//   The invoice is closed if the credit settles it.
+func (c *Context) ApplyCredit(credit *AmtCurTime) (leftover *AmtCurTime, err error) {
+	if !c.Open {
+		return credit, nil
+	}
+	unpaid, err := c.Unpaid()
+	if err != nil {
+		return credit, err
+	}
+	applied := credit
+	gte, err := credit.GTE(unpaid)
+	if err != nil {
+		return credit, err
+	}
+	if gte {
+		applied = unpaid
+		c.Open = false
+	}
+	c.Credited, err = c.Credited.Add(applied)
+	if err != nil {
+		return credit, err
+	}
+	return credit.Minus(applied)
+}
+
+// NewContract creates a new open Contract invoice pending approval
 func NewContract(ID []byte, Sender, Receiver, DepositInfo, Notes string,
 	AcceptedCur string, Due time.Time, Amount, Payable *AmtCurTime) *Contract {
 
@@ -111,9 +529,9 @@ func NewContract(ID []byte, Sender, Receiver, DepositInfo, Notes string,
 			Due:         Due,
 
 			Open:     true,
+			Approval: ApprovalPending,
 			Invoiced: Amount,
 			Payable:  Payable,
-			Paid:     nil,
 		},
 	}
 }
@@ -142,7 +560,7 @@ type Expense struct {
 	ExpenseTaxes *AmtCurTime
 }
 
-// NewExpense creates a new open Expense invoice
+// NewExpense creates a new open Expense invoice pending approval
 func NewExpense(ID []byte, Sender, Receiver, DepositInfo, Notes string,
 	AcceptedCur string, Due time.Time, Amount, Payable *AmtCurTime,
 	Document []byte, DocFileName string, ExpenseTaxes *AmtCurTime) *Expense {
@@ -158,9 +576,9 @@ func NewExpense(ID []byte, Sender, Receiver, DepositInfo, Notes string,
 			Due:         Due,
 
 			Open:     true,
+			Approval: ApprovalPending,
 			Invoiced: Amount,
 			Payable:  Payable,
-			Paid:     nil,
 		},
 		Document:     Document,
 		DocFileName:  DocFileName,
@@ -183,6 +601,89 @@ func (e *Expense) GetCtx() *Context {
 	return e.Ctx
 }
 
+// CreditNote state struct of type Invoice, a credit from the sender to the
+//   receiver which offsets the unpaid portion of the invoices it references.
+//   The Ctx allocations record the credit applied to each invoice, the credit
+//   note remains open while any credit is unapplied and may be applied to
+//   later invoices.
+type CreditNote struct {
+	ID         []byte
+	Ctx        *Context
+	InvoiceIDs [][]byte //Invoices the credit note was issued against
+}
+
+// NewCreditNote creates a new open CreditNote
+func NewCreditNote(ID []byte, Sender, Receiver, Notes string, AcceptedCur string,
+	Date time.Time, Amount, Payable *AmtCurTime, InvoiceIDs [][]byte) *CreditNote {
+
+	return &CreditNote{
+		ID: ID,
+		Ctx: &Context{
+			Sender:      Sender,
+			Receiver:    Receiver,
+			Notes:       Notes,
+			AcceptedCur: AcceptedCur,
+			Due:         Date,
+
+			Open:     true,
+			Approval: ApprovalApproved,
+			Invoiced: Amount,
+			Payable:  Payable,
+		},
+		InvoiceIDs: InvoiceIDs,
+	}
+}
+
+// SetID generates the CreditNote ID from the context and referenced invoices
+func (n *CreditNote) SetID() {
+	n.ID = merkle.SimpleHashFromBinary(struct {
+		Ctx        *Context
+		InvoiceIDs [][]byte
+	}{n.Ctx, n.InvoiceIDs})
+}
+
+// GetID get the CreditNote ID
+func (n *CreditNote) GetID() []byte {
+	return n.ID
+}
+
+// GetCtx return the context
+func (n *CreditNote) GetCtx() *Context {
+	return n.Ctx
+}
+
+// ApplyTo applies the unapplied credit to the invoice with the context ctx
+//   on the date, the credit note is closed once all of its credit is applied
+func (n *CreditNote) ApplyTo(id []byte, ctx *Context, date time.Time) error {
+	if !n.Ctx.Open || !ctx.Open {
+		return nil
+	}
+	remaining, err := n.Ctx.Unpaid()
+	if err != nil {
+		return err
+	}
+	leftover, err := ctx.ApplyCredit(remaining)
+	if err != nil {
+		return err
+	}
+	applied, err := remaining.Minus(leftover)
+	if err != nil {
+		return err
+	}
+	if applied.Amount != "0" {
+		n.Ctx.Allocations = append(n.Ctx.Allocations, Allocation{
+			TransactionID: hex.EncodeToString(id),
+			Amount:        applied,
+			Date:          date,
+			Rate:          "1",
+		})
+	}
+	if leftover.Amount == "0" {
+		n.Ctx.Open = false
+	}
+	return nil
+}
+
 /////////////////////////////////////////////////////////////////////////
 
 // Payment state struct for paying invoices
@@ -192,13 +693,17 @@ type Payment struct {
 	Sender         string   //Intended sender profile name of the payment
 	Receiver       string   //Intended receiver profile name of the payment
 	PaymentCurTime *AmtCurTime
-	StartDate      time.Time //Optional start date of payments to query for
-	EndDate        time.Time //Optional end date of payments to query
+	StartDate      time.Time           //Optional start date of payments to query for
+	EndDate        time.Time           //Optional end date of payments to query
+	Allocation     string              //Strategy used to allocate the payment between the invoices
+	Allocated      []InvoiceAllocation //Portion of the payment allocated to each invoice, in its payable currency
+	Excess         *AmtCurTime         //Portion of the payment kept as a credit balance, nil if none
+	Reversed       bool                //True once reversed or refunded, see the Reversal of the same transaction ID
 }
 
 // NewPayment creates a new payment state
 func NewPayment(InvoiceIDs [][]byte, TransactionID, Sender, Receiver string,
-	PaymentCurTime *AmtCurTime, StartDate, EndDate time.Time) *Payment {
+	PaymentCurTime *AmtCurTime, Allocation string, StartDate, EndDate time.Time) *Payment {
 
 	return &Payment{
 		TransactionID:  TransactionID,
@@ -206,7 +711,97 @@ func NewPayment(InvoiceIDs [][]byte, TransactionID, Sender, Receiver string,
 		Sender:         Sender,
 		Receiver:       Receiver,
 		PaymentCurTime: PaymentCurTime,
+		Allocation:     Allocation,
 		StartDate:      StartDate,
 		EndDate:        EndDate,
 	}
 }
+
+/////////////////////////////////////////////////////////////////////////
+
+//nolint Payment reversal kinds
+const (
+	ReversalBounced = "reversal" //the payment transfer failed
+	ReversalRefund  = "refund"   //the payment was returned to the payer
+)
+
+// Reversal state struct recording the reversal or refund of a payment, the
+//   allocations of the payment are unwound and the invoices reopened
+type Reversal struct {
+	TransactionID string              //Transaction ID of the payment reversed
+	Kind          string              //Kind of reversal, reversal or refund
+	RefundID      string              //Transaction ID of the transfer returning the funds, for refunds
+	Sender        string              //Profile name which recorded the reversal
+	Reason        string              //Reason the payment was reversed
+	Date          time.Time           //Block time at which the reversal was recorded
+	Unwound       []InvoiceAllocation //Allocations of the payment which were unwound
+}
+
+/////////////////////////////////////////////////////////////////////////
+
+// BalanceTransactionID is the transaction ID of allocations paid from a credit balance
+const BalanceTransactionID = "credit-balance"
+
+// Balance state struct for the credit held by the receiver from payments by
+//   the sender in excess of the invoices paid, the credit is applied to the
+//   next invoice from the receiver to the sender
+type Balance struct {
+	Sender   string      //Profile name of the payer which overpaid
+	Receiver string      //Profile name of the payee holding the credit
+	Credit   *AmtCurTime //Unapplied credit
+}
+
+/////////////////////////////////////////////////////////////////////////
+
+// Schedule state struct for recurring contract invoices, the template
+//   is issued as an invoice dated on each occurrence of the cadence
+type Schedule struct {
+	ID          []byte
+	Sender      string     //Sender profile name of the issued invoices
+	Receiver    string     //Receiver profile name of the issued invoices
+	Template    *TxInvoice //Invoice issued on each occurrence, the dates are ignored
+	Cadence     string     //Recurrence of the invoices, eg. monthly or a cron expression
+	Start       time.Time  //Time of the first occurrence
+	End         time.Time  //Optional time after which no invoices are issued, unset if before the start
+	Occurrences int        //Optional number of invoices to issue, 0 for no limit
+
+	Active     bool      //Is this schedule still issuing invoices
+	Next       time.Time //Time the next invoice is to be issued
+	Issued     int       //Number of occurrences issued or skipped so far
+	InvoiceIDs [][]byte  //IDs of the invoices issued
+	LastError  string    //Reason the most recent occurrence was skipped, if any
+}
+
+// NewSchedule creates a new active schedule
+func NewSchedule(Sender, Receiver string, Template *TxInvoice, Cadence string,
+	Start, End time.Time, Occurrences int, Next time.Time) *Schedule {
+
+	return &Schedule{
+		Sender:      Sender,
+		Receiver:    Receiver,
+		Template:    Template,
+		Cadence:     Cadence,
+		Start:       Start,
+		End:         End,
+		Occurrences: Occurrences,
+
+		Active: true,
+		Next:   Next,
+	}
+}
+
+// SetID generates the Schedule ID from its terms
+func (s *Schedule) SetID() {
+	s.ID = merkle.SimpleHashFromBinary(s)
+}
+
+// Finished returns true if no further invoices are to be issued,
+//   the next time is zero if the cadence has no further occurrences.
+//   An end before the start is unset, the zero time is not preserved
+//   when encoded.
+func (s *Schedule) Finished() bool {
+	return !s.Active ||
+		s.Next.IsZero() ||
+		(!s.End.Before(s.Start) && s.Next.After(s.End)) ||
+		(s.Occurrences > 0 && s.Issued >= s.Occurrences)
+}
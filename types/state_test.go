@@ -0,0 +1,122 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPay(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	amt, err := ParseAmtCurTime("1000USD", date)
+	require.Nil(err)
+	ctx := NewContract(nil, "foo", "bar", "", "", "USD", date.AddDate(0, 0, 30), amt, amt).Ctx
+	pay := func(transactionID, fund string, days int) *AmtCurTime {
+		f, err := ParseAmtCurTime(fund, date)
+		require.Nil(err)
+		leftover, err := ctx.Pay(transactionID, f, date.AddDate(0, 0, days))
+		require.Nil(err)
+		return leftover
+	}
+
+	//partial payments accumulate in the ledger
+	assert.Equal("0", pay("tx1", "300USD", 1).Amount)
+	assert.Equal("0", pay("tx2", "200USD", 2).Amount)
+	paid, err := ctx.Paid()
+	require.Nil(err)
+	assert.Equal("500", paid.Amount)
+	assert.True(ctx.Open)
+
+	//the settling payment only allocates the unpaid portion
+	assert.Equal("100", pay("tx3", "600USD", 3).Amount)
+	assert.False(ctx.Open)
+	require.Len(ctx.Allocations, 3)
+	assert.Equal("tx3", ctx.Allocations[2].TransactionID)
+	assert.Equal("500", ctx.Allocations[2].Amount.Amount)
+	assert.True(date.AddDate(0, 0, 3).Equal(ctx.Allocations[2].Date))
+	unpaid, err := ctx.Unpaid()
+	require.Nil(err)
+	assert.Equal("0", unpaid.Amount)
+
+	//invoices with payments cannot be voided, until the payments are reversed
+	ctx.Open = true
+	assert.NotNil(ctx.Void("mistake"))
+	for _, tx := range []string{"tx1", "tx2", "tx3"} {
+		require.Nil(ctx.Unpay(tx, date.AddDate(0, 0, 4)))
+	}
+	assert.Nil(ctx.Void("bounced"))
+
+	//nor can invoices which have been credited
+	ctx = NewContract(nil, "foo", "bar", "", "", "USD", date.AddDate(0, 0, 30), amt, amt).Ctx
+	_, err = ctx.ApplyCredit(&AmtCurTime{amt.CurTime, "100"})
+	require.Nil(err)
+	assert.NotNil(ctx.Credited)
+	assert.NotNil(ctx.Void("mistake"))
+}
+
+func TestPayAt(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	newCtx := func(payable string) *Context {
+		amt, err := ParseAmtCurTime(payable, date)
+		require.Nil(err)
+		return NewContract(nil, "foo", "bar", "", "", "BTC", date.AddDate(0, 0, 30), amt, amt).Ctx
+	}
+	payAt := func(ctx *Context, transactionID, fund string) *AmtCurTime {
+		f, err := ParseAmtCurTime(fund, date)
+		require.Nil(err)
+		leftover, err := ctx.PayAt(transactionID, f, "0.001", date)
+		require.Nil(err)
+		return leftover
+	}
+
+	//a partial payment is converted to the payable currency
+	ctx := newCtx("0.01234567BTC")
+	assert.Equal("0", payAt(ctx, "tx1", "5USD").Amount)
+	assert.Equal("0.005", ctx.Allocations[0].Amount.Amount)
+	assert.Equal("0.001", ctx.Allocations[0].Rate)
+	assert.Nil(ctx.Allocations[0].FXGainLoss)
+	required, err := ctx.UnpaidAt("USD", "0.001", date)
+	require.Nil(err)
+	assert.Equal("7.35", required.Amount)
+
+	//settlement rounded up in the payment currency realizes a gain
+	assert.Equal("2.65", payAt(ctx, "tx2", "10USD").Amount)
+	assert.False(ctx.Open)
+	assert.Equal("0.00735", ctx.Allocations[1].Amount.Amount)
+	fx, err := ctx.FXGainLoss()
+	require.Nil(err)
+	assert.Equal("0.00000433", fx.Amount)
+	unpaid, err := ctx.Unpaid()
+	require.Nil(err)
+	assert.Equal("0", unpaid.Amount)
+
+	//unwinding the settlement also unwinds the gain
+	require.Nil(ctx.Unpay("tx2", date))
+	assert.True(ctx.Open)
+	fx, err = ctx.FXGainLoss()
+	require.Nil(err)
+	assert.Equal("0", fx.Amount)
+	unpaid, err = ctx.Unpaid()
+	require.Nil(err)
+	assert.Equal("0.00734567", unpaid.Amount)
+
+	//settlement rounded down in the payment currency realizes a loss
+	ctx = newCtx("0.012345BTC")
+	assert.Equal("0", payAt(ctx, "tx3", "12.34USD").Amount)
+	assert.False(ctx.Open)
+	fx, err = ctx.FXGainLoss()
+	require.Nil(err)
+	assert.Equal("-0.000005", fx.Amount)
+
+	//a payment in another currency requires a rate
+	ctx = newCtx("1BTC")
+	f, err := ParseAmtCurTime("1USD", date)
+	require.Nil(err)
+	_, err = ctx.Pay("tx4", f, date)
+	assert.NotNil(err)
+}
@@ -0,0 +1,19 @@
+package types
+
+// TaxLine is a single tax levied on an invoice
+type TaxLine struct {
+	Name         string      //Name of the tax eg. VAT, GST, PST
+	Jurisdiction string      //Jurisdiction which levies the tax
+	Rate         string      //Fraction of the base amount, eg. 0.2 for 20%
+	Amount       *AmtCurTime //Amount of tax
+}
+
+// TaxBreakdown records how the tax on an invoice was calculated
+type TaxBreakdown struct {
+	Base          *AmtCurTime //Amount the tax was calculated from
+	Lines         []TaxLine   //Individual taxes levied
+	Total         *AmtCurTime //Total of all the tax lines
+	Inclusive     bool        //Tax is included in the base rather than added to it
+	ReverseCharge bool        //Tax is accounted for by the receiver rather than the sender
+	Note          string      //Reason for the tax treatment
+}
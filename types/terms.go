@@ -0,0 +1,96 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentTerms are the terms an invoice is to be paid within, an early
+//   payment discount may be offered when paid within the discount window
+type PaymentTerms struct {
+	DiscountRate string //Fraction of the payable amount discounted, eg. 0.02 for 2%
+	DiscountDays int    //Days from the invoice date in which the discount is available
+	NetDays      int    //Days from the invoice date until the invoice is due
+}
+
+var termsRe = regexp.MustCompile(`^(?:(\d+(?:\.\d+)?)%?/(\d+)\s*,?\s*)?(?:net|n/)\s*(\d+)$`)
+
+// ParsePaymentTerms parses payment terms in the conventional format, such as
+//   "net 30", "2/10 net 30" or "1.5/15 n/45", where "2/10 net 30" is a 2%
+//   discount if paid within 10 days otherwise the full amount due in 30 days
+func ParsePaymentTerms(terms string) (*PaymentTerms, error) {
+	matches := termsRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(terms)))
+	if matches == nil {
+		return nil, errors.Errorf("bad payment terms %v, must be in the format "+
+			"<discount %%>/<discount days> net <days> eg. 2/10 net 30, or net <days>", terms)
+	}
+
+	out := &PaymentTerms{DiscountRate: "0"}
+	out.NetDays, _ = strconv.Atoi(matches[3])
+	if len(matches[1]) > 0 {
+		percent, err := decimal.NewFromString(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		out.DiscountRate = percent.Div(decimal.New(100, 0)).String()
+		out.DiscountDays, _ = strconv.Atoi(matches[2])
+	}
+	return out, out.Validate()
+}
+
+// Validate checks the payment terms are consistent
+func (t *PaymentTerms) Validate() error {
+	rate, err := decimal.NewFromString(t.DiscountRate)
+	if err != nil {
+		return errors.Wrap(err, "bad payment terms discount")
+	}
+	switch {
+	case t.NetDays < 0 || t.DiscountDays < 0:
+		return errors.New("payment terms cannot have negative days")
+	case rate.Sign() < 0 || !rate.LessThan(decimal.New(1, 0)):
+		return errors.New("payment terms discount must be at least 0% and less than 100%")
+	case rate.Sign() > 0 && t.DiscountDays > t.NetDays:
+		return errors.New("payment terms discount days cannot exceed the net days")
+	}
+	return nil
+}
+
+// String returns the payment terms in the conventional format
+func (t *PaymentTerms) String() string {
+	rate, err := decimal.NewFromString(t.DiscountRate)
+	if err != nil || rate.Sign() == 0 {
+		return fmt.Sprintf("net %v", t.NetDays)
+	}
+	return fmt.Sprintf("%v/%v net %v", rate.Mul(decimal.New(100, 0)).String(), t.DiscountDays, t.NetDays)
+}
+
+// DiscountDeadline is the last day on which the discount is available
+//   for an invoice dated on the issued date
+func (t *PaymentTerms) DiscountDeadline(issued time.Time) time.Time {
+	return issued.AddDate(0, 0, t.DiscountDays)
+}
+
+// Discount calculates the early payment discount of the payable amount
+//   if paid on the date, for an invoice dated on the issued date
+func (t *PaymentTerms) Discount(payable *AmtCurTime, issued, date time.Time) (*AmtCurTime, error) {
+	rate, err := decimal.NewFromString(t.DiscountRate)
+	if err != nil {
+		return nil, err
+	}
+
+	//the discount is available until the end of the deadline day
+	if date.After(t.DiscountDeadline(issued).AddDate(0, 0, 1).Add(-time.Nanosecond)) {
+		rate = decimal.New(0, 0)
+	}
+	amt, err := decimal.NewFromString(payable.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return NewAmtCurTime(payable.CurTime.Cur, payable.CurTime.Date, amt.Mul(rate))
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaymentTerms(t *testing.T) {
+	assert := assert.New(t)
+
+	var testTerms = []struct {
+		in           string
+		rate         string
+		discountDays int
+		netDays      int
+		errNil       bool
+	}{
+		{"net 30", "0", 0, 30, true},
+		{"Net30", "0", 0, 30, true},
+		{"2/10 net 30", "0.02", 10, 30, true},
+		{"2%/10, net 30", "0.02", 10, 30, true},
+		{"1.5/15 n/45", "0.015", 15, 45, true},
+		{"2/40 net 30", "", 0, 0, false},
+		{"100/10 net 30", "", 0, 0, false},
+		{"2/10", "", 0, 0, false},
+		{"30 days", "", 0, 0, false},
+	}
+
+	for _, test := range testTerms {
+		terms, err := ParsePaymentTerms(test.in)
+		if !test.errNil {
+			assert.NotNil(err, test.in)
+			continue
+		}
+		if assert.Nil(err, test.in) {
+			assert.Equal(test.rate, terms.DiscountRate, test.in)
+			assert.Equal(test.discountDays, terms.DiscountDays, test.in)
+			assert.Equal(test.netDays, terms.NetDays, test.in)
+		}
+	}
+}
+
+func TestPayDiscount(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	terms, err := ParsePaymentTerms("2/10 net 30")
+	require.Nil(err)
+	amt, err := ParseAmtCurTime("1000USD", date)
+	require.Nil(err)
+	newCtx := func() *Context {
+		ctx := NewContract(nil, "foo", "bar", "", "", "USD", date.AddDate(0, 0, 30), amt, amt).Ctx
+		ctx.Terms = terms
+		return ctx
+	}
+
+	//within the window the discount is taken when settling in full
+	ctx := newCtx()
+	unpaid, err := ctx.UnpaidOn(date.AddDate(0, 0, 10))
+	require.Nil(err)
+	assert.Equal("980", unpaid.Amount)
+	fund, err := ParseAmtCurTime("1000USD", date)
+	require.Nil(err)
+	leftover, err := ctx.Pay("tx", fund, date.AddDate(0, 0, 10))
+	require.Nil(err)
+	assert.Equal("20", leftover.Amount)
+	assert.Equal("20", ctx.Discount.Amount)
+	paid, err := ctx.Paid()
+	require.Nil(err)
+	assert.Equal("980", paid.Amount)
+	assert.False(ctx.Open)
+	unpaid, err = ctx.Unpaid()
+	require.Nil(err)
+	assert.Equal("0", unpaid.Amount)
+
+	//after the window the full amount is due
+	ctx = newCtx()
+	unpaid, err = ctx.UnpaidOn(date.AddDate(0, 0, 11))
+	require.Nil(err)
+	assert.Equal("1000", unpaid.Amount)
+	fund, err = ParseAmtCurTime("1000USD", date)
+	require.Nil(err)
+	_, err = ctx.Pay("tx", fund, date.AddDate(0, 0, 11))
+	require.Nil(err)
+	assert.Nil(ctx.Discount)
+	assert.False(ctx.Open)
+}
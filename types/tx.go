@@ -7,6 +7,22 @@ type TxProfile struct {
 	AcceptedCur     string
 	DepositInfo     string
 	DueDurationDays int
+	TaxJurisdiction string
+	TaxID           string
+	Terms           string
+	LateFees        string
+	TrustedSenders  []string
+	Signers         [][]byte
+	Threshold       int
+	Limit           string
+}
+
+// TxProfileRotateKey is the transaction struct sent through tendermint
+//   to move a profile from the signing address to a new address
+type TxProfileRotateKey struct {
+	Name       string
+	SenderAddr []byte
+	NewAddress []byte
 }
 
 // TxInvoice is the transaction struct sent through tendermint
@@ -22,6 +38,85 @@ type TxInvoice struct {
 	DueDate     string
 	Receipt     string
 	TaxesPaid   string
+	LineItems   []TxLineItem
+	Terms       string
+	LateFees    string
+}
+
+// TxInvoiceVoid is the transaction struct sent through tendermint
+type TxInvoiceVoid struct {
+	ID         []byte
+	SenderAddr []byte
+	Reason     string
+}
+
+// TxInvoiceApproval is the transaction struct sent through tendermint
+//   to approve or reject an invoice
+type TxInvoiceApproval struct {
+	ID         []byte
+	SenderAddr []byte
+	Comment    string
+}
+
+// TxInvoiceDispute is the transaction struct sent through tendermint
+//   to dispute an invoice, the evidence is the hash of a supporting document
+type TxInvoiceDispute struct {
+	ID         []byte
+	SenderAddr []byte
+	Reason     string
+	Evidence   []byte
+}
+
+// TxInvoiceRespond is the transaction struct sent through tendermint
+//   to resolve the dispute of an invoice
+type TxInvoiceRespond struct {
+	ID         []byte
+	SenderAddr []byte
+	Resolution string
+	Response   string
+}
+
+// TxActionSign is the transaction struct sent through tendermint
+//   to approve the pending action of a multi-signature profile
+type TxActionSign struct {
+	ID         []byte
+	SenderAddr []byte
+}
+
+// TxOrgMember is the transaction struct sent through tendermint
+//   to add, update or remove a member of an organisation profile
+type TxOrgMember struct {
+	Org        string
+	SenderAddr []byte
+	Address    []byte
+	Roles      []string
+}
+
+// TxOrgChain is the transaction struct sent through tendermint
+//   to configure the approval chain of an organisation profile
+type TxOrgChain struct {
+	Org        string
+	SenderAddr []byte
+	Steps      []ApprovalStep
+}
+
+// TxCreditNote is the transaction struct sent through tendermint
+type TxCreditNote struct {
+	SenderAddr []byte
+	InvoiceIDs [][]byte
+	Amount     string
+	Notes      string
+	Date       string
+}
+
+// TxLineItem is an invoice line item as sent through tendermint,
+//   the unit price is in the format <decimal><currency>
+type TxLineItem struct {
+	Description string `json:"description" yaml:"description"`
+	Quantity    string `json:"quantity" yaml:"quantity"`
+	UnitPrice   string `json:"unit_price" yaml:"unit_price"`
+	TaxRate     string `json:"tax_rate,omitempty" yaml:"tax_rate,omitempty"`
+	Discount    string `json:"discount,omitempty" yaml:"discount,omitempty"`
 }
 
 // TxPayment is the transaction struct sent through tendermint
@@ -32,4 +127,39 @@ type TxPayment struct {
 	Receiver      string
 	Amt           *AmtCurTime
 	DateRange     string
+	Allocation    string        //Allocation strategy of the payment between the invoices
+	Amounts       []*AmtCurTime //Amount paid to each of the IDs for the explicit allocation strategy
+	Org           string        //Organisation paying, when sent by a member with the payer role
+}
+
+// TxPaymentReverse is the transaction struct sent through tendermint
+//   to reverse or refund a payment
+type TxPaymentReverse struct {
+	TransactionID string //Transaction ID of the payment to reverse
+	SenderAddr    []byte
+	RefundID      string //Transaction ID of the transfer returning the funds, for refunds
+	Reason        string
+}
+
+// TxRate is the transaction struct sent through tendermint
+type TxRate struct {
+	From string
+	To   string
+	Rate string
+	Date string
+}
+
+// TxScheduleOpen is the transaction struct sent through tendermint
+type TxScheduleOpen struct {
+	Invoice     TxInvoice
+	Cadence     string
+	Start       string
+	End         string
+	Occurrences int
+}
+
+// TxScheduleCancel is the transaction struct sent through tendermint
+type TxScheduleCancel struct {
+	ID         []byte
+	SenderAddr []byte
 }